@@ -2,7 +2,6 @@
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"log"
@@ -33,10 +32,16 @@ func cat(tablePaths []string) {
 			return
 		}
 
-		for c := sstable.NewRecordIOReader(f, uint64(size)); !c.Done(); c.Next() {
+		c := sstable.NewRecordIOReader(f, uint64(size))
+		for ; !c.Done(); c.Next() {
 			fmt.Println(string(c.Entry().Key))
 			fmt.Println(string(c.Entry().Value))
 		}
+
+		if err := c.Err(); err != nil {
+			log.Println("Error on reading path", tablePath, ":", err)
+			return
+		}
 	}
 }
 
@@ -55,9 +60,15 @@ func lss(tablePaths []string) {
 			return
 		}
 
-		for c := tbl.ScanFrom(nil); !c.Done(); c.Next() {
+		c := tbl.ScanFrom(nil)
+		for ; !c.Done(); c.Next() {
 			fmt.Println(string(c.Entry().Key))
 		}
+
+		if err := c.Err(); err != nil {
+			log.Println("Error on reading path", tablePath, ":", err)
+			return
+		}
 	}
 }
 
@@ -76,12 +87,16 @@ func cats(tablePath string, key string) {
 		return
 	}
 
-	c := tbl.ScanFrom([]byte(key))
-	if c.Done() || !bytes.Equal(c.Entry().Key, []byte(key)) {
+	value, found, err := tbl.Get([]byte(key))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if !found {
 		return
 	}
 
-	value := c.Entry().Value
 	fmt.Println(string(value))
 }
 
@@ -131,14 +146,82 @@ func convert(from, to string) {
 	w := sstable.NewWriter(t)
 	defer w.Close()
 
-	for c := sstable.NewRecordIOReader(f, uint64(size)); !c.Done(); c.Next() {
+	c := sstable.NewRecordIOReader(f, uint64(size))
+	for ; !c.Done(); c.Next() {
 		if err := w.Write(*c.Entry()); err != nil {
 			log.Printf("Error on writing to sstable %q: %+v", to, err)
 		}
 	}
+
+	if err := c.Err(); err != nil {
+		log.Printf("Error on reading path %q: %+v", from, err)
+	}
+}
+
+// verify prints the problems of each path in the SSTable.
+func verify(tablePaths []string) {
+	for _, tablePath := range tablePaths {
+		f, err := os.Open(tablePath)
+		if err != nil {
+			log.Println("Error on opening path", tablePath, ":", err)
+			return
+		}
+		defer f.Close()
+
+		problems, err := sstable.Verify(f, sstable.VerifyOptions{})
+		if err != nil {
+			log.Println("Error on verifying path", tablePath, ":", err)
+			return
+		}
+
+		for _, p := range problems {
+			fmt.Println(tablePath, ":", p)
+		}
+	}
+}
+
+// tableFile is the output file of recoverTable without its Close, so
+// that recoverTable rather than the Writer closes the file exactly once.
+type tableFile struct {
+	f *os.File
+}
+
+func (t tableFile) Write(p []byte) (int, error) { return t.f.Write(p) }
+
+func (t tableFile) WriteAt(p []byte, off int64) (int, error) { return t.f.WriteAt(p, off) }
+
+// recoverTable writes the entries that can be salvaged from the damaged
+// SSTable to a new SSTable and prints what was lost.
+func recoverTable(from, to string) error {
+	f, err := os.Open(from)
+	if err != nil {
+		return fmt.Errorf("failed to open path %q: %w", from, err)
+	}
+	defer f.Close()
+
+	t, err := os.Create(to)
+	if err != nil {
+		return fmt.Errorf("failed to create path %q: %w", to, err)
+	}
+
+	report, err := sstable.Recover(f, sstable.NewWriter(tableFile{t}), sstable.RecoverOptions{Resync: true})
+	if cerr := t.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to close path %q: %w", to, cerr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to recover path %q to %q: %w", from, to, err)
+	}
+
+	fmt.Println("Recovered", report.Entries, "entries")
+
+	for _, d := range report.Damage {
+		fmt.Println(from, ": lost", d.Length, "bytes at offset", d.Offset, ":", d.Reason)
+	}
+
+	return nil
 }
 
-// help prints help message. If cmd is empty, prints the list of commands.
 func help(cmd string) {
 	helpDetails := map[string]string{
 		"cat":     "cat path [path...] - prints the all keys and values in the RecordIO",
@@ -146,6 +229,8 @@ func help(cmd string) {
 		"cats":    "cats path key - prints the value in the SSTable",
 		"append":  "append path key value - append key, value to path in RecordIO",
 		"convert": "convert from to - convert a RecordIO file to an SSTable. RecordIO should be already sorted",
+		"verify":  "verify path [path...] - prints the problems of each path in the SSTable",
+		"recover": "recover from to - writes the entries that can be salvaged from a damaged SSTable to a new SSTable",
 	}
 
 	if cmd == "" {
@@ -209,5 +294,21 @@ func main() {
 		}
 
 		convert(args[1], args[2])
+	case "verify":
+		if len(args) < 2 {
+			help("verify")
+			return
+		}
+
+		verify(args[1:])
+	case "recover":
+		if len(args) < 3 {
+			help("recover")
+			return
+		}
+
+		if err := recoverTable(args[1], args[2]); err != nil {
+			log.Printf("Error on recovering: %+v", err)
+		}
 	}
 }
@@ -2,7 +2,6 @@
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"log"
@@ -41,16 +40,19 @@ func indexHandler(tbl *sstable.SSTable) func(w http.ResponseWriter, r *http.Requ
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, "<!DOCTYPE html>\n<html><body><ol>\n")
 
-		for c := tbl.ScanFrom(from); !c.Done(); c.Next() {
+		c := tbl.ScanRange(from, to, sstable.ScanOptions{EndInclusive: true})
+		for ; !c.Done(); c.Next() {
 			e := c.Entry()
-			if to != nil && bytes.Compare(to, e.Key) < 0 {
-				break
-			}
-
 			href := "/lookup?key=" + url.QueryEscape(string(e.Key))
 			fmt.Fprintf(w, "<li><a href=\"%s\">%s</a></li>", href, string(e.Key))
 		}
-		fmt.Fprint(w, "</ol></body></html>\n")
+		fmt.Fprint(w, "</ol>\n")
+
+		if err := c.Err(); err != nil {
+			log.Printf("Error scanning the table: %+v", err)
+			fmt.Fprint(w, "<p>The list is incomplete because the table could not be read.</p>\n")
+		}
+		fmt.Fprint(w, "</body></html>\n")
 	}
 }
 
@@ -60,19 +62,18 @@ func lookupHandler(tbl *sstable.SSTable) func(w http.ResponseWriter, r *http.Req
 			http.NotFound(w, r)
 			return
 		}
-		key := []byte(r.FormValue("key"))
-		c := tbl.ScanFrom(key)
-		if c.Done() {
-			http.NotFound(w, r)
+		value, found, err := tbl.Get([]byte(r.FormValue("key")))
+		if err != nil {
+			log.Printf("Error looking up the key: %+v", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 			return
 		}
-		e := c.Entry()
-		if !bytes.Equal(key, e.Key) {
+		if !found {
 			http.NotFound(w, r)
 			return
 		}
-		w.Header().Set("Content-Type", http.DetectContentType(e.Value))
-		if _, err := w.Write(e.Value); err != nil {
+		w.Header().Set("Content-Type", http.DetectContentType(value))
+		if _, err := w.Write(value); err != nil {
 			log.Printf("Error writing the value to response writer: %+v", err)
 		}
 	}
@@ -80,8 +81,9 @@ func lookupHandler(tbl *sstable.SSTable) func(w http.ResponseWriter, r *http.Req
 
 func main() {
 	var (
-		path = flag.String("path", "", "path of SSTable filename")
-		addr = flag.String("addr", ":9001", "address of the web server")
+		path      = flag.String("path", "", "path of SSTable filename")
+		addr      = flag.String("addr", ":9001", "address of the web server")
+		cacheSize = flag.Int64("cache_size", 32<<20, "bytes of data blocks to cache in memory")
 	)
 
 	flag.Parse()
@@ -95,7 +97,7 @@ func main() {
 		log.Fatal("Open file failed:", err)
 	}
 
-	tbl, err := sstable.NewSSTable(f)
+	tbl, err := sstable.NewSSTable(f, sstable.WithBlockCache(sstable.NewBlockCache(*cacheSize)))
 	if err != nil {
 		log.Fatal("SSTable creation failed:", err)
 	}
@@ -21,13 +21,21 @@ func (es Entries) Len() int {
 	return len(es)
 }
 
-// Less implements the sort.Interface interface.
+// Less implements the sort.Interface interface. It orders the entries
+// by the keys and then by the values in bytewise order.
 func (es Entries) Less(i, j int) bool {
-	c := bytes.Compare(es[i].Key, es[j].Key)
+	return less(&es[i].Entry, &es[j].Entry, sstable.BytewiseComparer)
+}
+
+// less orders the entries by the keys in the order of cmp and then by
+// the values in bytewise order.
+func less(a, b *sstable.Entry, cmp sstable.Comparer) bool {
+	c := cmp.Compare(a.Key, b.Key)
 	if c == 0 {
-		return bytes.Compare(es[i].Value, es[j].Value) == -1
+		return bytes.Compare(a.Value, b.Value) < 0
 	}
-	return c == -1 //nolint:wsl
+
+	return c < 0
 }
 
 // Swap implements the sort.Interface interface.
@@ -51,3 +59,15 @@ func (es *Entries) Pop() interface{} {
 
 	return last
 }
+
+// comparerEntries implements the heap.Interface interface with the keys
+// in the order of cmp.
+type comparerEntries struct {
+	Entries
+	cmp sstable.Comparer
+}
+
+// Less implements the sort.Interface interface.
+func (es *comparerEntries) Less(i, j int) bool {
+	return less(&es.Entries[i].Entry, &es.Entries[j].Entry, es.cmp)
+}
@@ -7,25 +7,29 @@ import (
 	"github.com/jaeyeom/sstable/go/sstable"
 )
 
-// SortEntries sorts entries from c in memory and write to w in
-// slightly over the maxSize bytes. Returns number of entries written
-// and error.
+// SortEntries sorts entries from c in memory in the order of the
+// comparer of w and write to w in slightly over the maxSize bytes.
+// Returns number of entries written and error.
 //
 //nolint:revive // intentionally named SortEntries for clarity
 func SortEntries(c sstable.Cursor, maxSize uint64, w *sstable.Writer) (n int, err error) {
-	es, size := Entries{}, uint64(0)
+	es, size := &comparerEntries{cmp: w.Comparer()}, uint64(0)
 	for !c.Done() && size < maxSize {
 		e := c.Entry()
 		c.Next()
 
 		size += e.Size()
-		es = append(es, HeapEntry{*e, nil})
+		es.Entries = append(es.Entries, HeapEntry{*e, nil})
 	}
 
-	heap.Init(&es)
+	if err := c.Err(); err != nil {
+		return 0, err
+	}
+
+	heap.Init(es)
 
 	for es.Len() > 0 {
-		e := heap.Pop(&es)
+		e := heap.Pop(es)
 
 		err = w.Write(e.(HeapEntry).Entry)
 		if err != nil {
@@ -37,30 +41,37 @@ func SortEntries(c sstable.Cursor, maxSize uint64, w *sstable.Writer) (n int, er
 	return n, w.Close()
 }
 
-// Merge merges from multiple cursors and write SSTable to w.
+// Merge merges from multiple cursors in the order of the comparer of w
+// and write SSTable to w.
 func Merge(cursors []sstable.Cursor, w *sstable.Writer) error {
-	var es Entries
+	es := &comparerEntries{cmp: w.Comparer()}
 
 	for i, c := range cursors {
 		if c.Done() {
+			if err := c.Err(); err != nil {
+				return err
+			}
+
 			continue
 		}
 
 		e := c.Entry()
 		c.Next()
 
-		es = append(es, HeapEntry{*e, i})
+		es.Entries = append(es.Entries, HeapEntry{*e, i})
 	}
 
-	heap.Init(&es)
+	heap.Init(es)
 
 	for es.Len() > 0 {
-		e := heap.Pop(&es).(HeapEntry)
+		e := heap.Pop(es).(HeapEntry)
 
 		i := e.data.(int)
 		if !cursors[i].Done() {
-			heap.Push(&es, HeapEntry{*cursors[i].Entry(), i})
+			heap.Push(es, HeapEntry{*cursors[i].Entry(), i})
 			cursors[i].Next()
+		} else if err := cursors[i].Err(); err != nil {
+			return err
 		}
 
 		if err := w.Write(e.Entry); err != nil {
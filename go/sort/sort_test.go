@@ -2,6 +2,7 @@
 package sort
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 
@@ -48,6 +49,53 @@ func ExampleSortEntries() {
 	// &{[4] []}
 }
 
+// reverseComparer orders the keys in reverse bytewise order. It returns
+// 2 and -2 rather than 1 and -1, which Comparers are free to do.
+type reverseComparer struct{}
+
+func (reverseComparer) Compare(a, b []byte) int {
+	return 2 * bytes.Compare(b, a)
+}
+
+func (reverseComparer) Name() string {
+	return "example.reverse"
+}
+
+func ExampleSortEntries_comparer() {
+	c := &SliceCursor{
+		sstable.Entry{Key: []byte{3}},
+		sstable.Entry{Key: []byte{2}},
+		sstable.Entry{Key: []byte{4}},
+		sstable.Entry{Key: []byte{1}},
+	}
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := sstable.NewWriter(f, sstable.WithComparer(reverseComparer{}))
+	fmt.Println(SortEntries(c, 100, w))
+
+	outf, _ := os.Open(name)
+	defer outf.Close()
+
+	s, err := sstable.NewSSTable(outf, sstable.ExpectComparer(reverseComparer{}))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for key := range s.All() {
+		fmt.Println(key)
+	}
+	// Output:
+	// 4 <nil>
+	// [4]
+	// [3]
+	// [2]
+	// [1]
+}
+
 //nolint:funlen
 func Example_sort() {
 	c := &SliceCursor{
@@ -145,6 +193,10 @@ func (c *SliceCursor) Done() bool {
 	return len(*c) == 0
 }
 
+func (c *SliceCursor) Err() error {
+	return nil
+}
+
 //nolint:funlen
 func ExampleMerge() {
 	f, _ := os.CreateTemp("", "")
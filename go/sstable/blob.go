@@ -0,0 +1,199 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Large values of format version 10 and later are stored out of line,
+// so that the Writer never holds them in memory, and neither does the
+// SSTable if they are read with OpenValue.
+// The Writer copies the value into a blob frame between the data
+// blocks: a block header of blobFrame, the length of the value as a
+// uint64, the value and its checksum if the table has checksums. The
+// entry of the value is a data block of its own with blobEncoding,
+// which holds the key and the blobRef of the value.
+
+// blobFrame is the codec ID of the block header of a blob frame. It is
+// not a real codec.
+const blobFrame CodecID = 0xfe
+
+// blobFrameSize is the number of bytes of a blob frame before the
+// value.
+const blobFrameSize = blockHeaderSize + 8
+
+// blobRef locates a value that is stored out of line.
+type blobRef struct {
+	offset uint64
+	length uint64
+}
+
+// marshalBlobBlock returns the raw block of the entry of a large value.
+func marshalBlobBlock(key []byte, ref *blobRef) ([]byte, error) {
+	if len(key) > math.MaxUint32 {
+		return nil, errors.New("marshalBlobBlock: key too long")
+	}
+
+	raw := make([]byte, 0, 1+4+len(key)+16)
+	raw = append(raw, blobEncoding)
+	raw = binary.BigEndian.AppendUint32(raw, uint32(len(key))) //nolint:gosec // overflow checked above
+	raw = append(raw, key...)
+	raw = binary.BigEndian.AppendUint64(raw, ref.offset)
+
+	return binary.BigEndian.AppendUint64(raw, ref.length), nil
+}
+
+// unmarshalBlobBlock parses the raw block of the entry of a large value
+// without the encoding byte. The key points into the block.
+func unmarshalBlobBlock(b block) (*Entry, *blobRef, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("unmarshalBlobBlock: truncated block: %w", ErrCorrupt)
+	}
+
+	keyLength := uint64(binary.BigEndian.Uint32(b[:4]))
+	if uint64(len(b)) != 4+keyLength+16 {
+		return nil, nil, fmt.Errorf("unmarshalBlobBlock: invalid length: %w", ErrCorrupt)
+	}
+
+	key := b[4 : 4+keyLength : 4+keyLength]
+	ref := &blobRef{
+		offset: binary.BigEndian.Uint64(b[4+keyLength:]),
+		length: binary.BigEndian.Uint64(b[12+keyLength:]),
+	}
+
+	return &Entry{Key: key}, ref, nil
+}
+
+// blobRef returns the blobRef of the raw block if it is the entry of a
+// value that is stored out of line, or nil otherwise.
+func (f blockFormat) blobRef(b block) (*blobRef, error) {
+	encoding, b, err := f.encoding(b)
+	if err != nil || encoding != blobEncoding {
+		return nil, err
+	}
+
+	_, ref, err := unmarshalBlobBlock(b)
+
+	return ref, err
+}
+
+// readBlobFrame reads the rest of the blob frame at offset whose block
+// header was read from r within the limits. It returns the blobRef and
+// the value, and the number of bytes of the frame.
+func (f blockFormat) readBlobFrame(r io.Reader, offset uint64, verify bool, limits ReadLimits) (*blobRef, []byte, uint64, error) {
+	var length [8]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, nil, 0, err
+	}
+
+	ref := &blobRef{offset: offset + blobFrameSize, length: binary.BigEndian.Uint64(length[:])}
+	if err := limits.checkEntry(0, ref.length); err != nil {
+		return nil, nil, 0, err
+	}
+
+	value, err := readFull(r, ref.length)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if f.trailerSize() > 0 {
+		var trailer [checksumSize]byte
+		if _, err := io.ReadFull(r, trailer[:]); err != nil {
+			return nil, nil, 0, err
+		}
+
+		if verify && binary.BigEndian.Uint32(trailer[:]) != checksum(value) {
+			return nil, nil, 0, &CorruptionError{Offset: ref.offset, What: "value"}
+		}
+	}
+
+	return ref, value, blobFrameSize + ref.length + uint64(f.trailerSize()), nil
+}
+
+// blobReader reads a large value and verifies its checksum at the end.
+type blobReader struct {
+	r        *io.SectionReader
+	trailer  io.ReaderAt
+	offset   uint64
+	verify   bool
+	crc      uint32
+	verified bool
+}
+
+// newBlobReader returns a reader of the value that ref locates in r.
+func newBlobReader(r io.ReaderAt, ref *blobRef, verify bool) (*blobReader, error) {
+	if ref.offset > math.MaxInt64 || ref.length > math.MaxInt64-ref.offset {
+		return nil, fmt.Errorf("newBlobReader: value %w: %d", ErrOffsetOverflow, ref.offset)
+	}
+
+	return &blobReader{
+		r:       io.NewSectionReader(r, int64(ref.offset), int64(ref.length)), //nolint:gosec // overflow checked above
+		trailer: r,
+		offset:  ref.offset,
+		verify:  verify,
+	}, nil
+}
+
+// Read implements the io.Reader interface. It returns a
+// *CorruptionError instead of io.EOF if the checksum doesn't match.
+func (b *blobReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if !b.verify {
+		return n, err
+	}
+
+	b.crc = crc32.Update(b.crc, crcTable, p[:n])
+
+	if err == io.EOF && !b.verified {
+		var trailer [checksumSize]byte
+
+		end := int64(b.offset) + b.r.Size() //nolint:gosec // checked by newBlobReader
+		if m, rerr := b.trailer.ReadAt(trailer[:], end); m != len(trailer) {
+			if rerr == nil || rerr == io.EOF {
+				rerr = io.ErrUnexpectedEOF
+			}
+
+			return n, rerr
+		}
+
+		if binary.BigEndian.Uint32(trailer[:]) != b.crc {
+			return n, &CorruptionError{Offset: b.offset, What: "value"}
+		}
+
+		b.verified = true
+	}
+
+	return n, err
+}
+
+// skipBlobs returns the offset after the blob frames at offset in a
+// table of the version whose blocks end at end.
+func skipBlobs(r io.ReaderAt, version uint32, offset, end uint64) uint64 {
+	if version < versionLargeValues {
+		return offset
+	}
+
+	format := blockFormatOf(version)
+
+	for offset < end {
+		sr := io.NewSectionReader(r, int64(offset), int64(end-offset)) //nolint:gosec // bounded by the end
+
+		var h [blockHeaderSize]byte
+		if _, err := io.ReadFull(sr, h[:]); err != nil || CodecID(h[4]) != blobFrame {
+			return offset
+		}
+
+		n, err := format.skipBlob(sr)
+		if err != nil {
+			return offset
+		}
+
+		offset += n
+	}
+
+	return offset
+}
@@ -0,0 +1,113 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func ExampleWriter_WriteValueFrom() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithLargeValues(), WithChecksums())
+	_ = w.Write(Entry{Key: []byte("apple"), Value: []byte("red")})
+
+	large := strings.Repeat("yellow", 1000)
+	if err := w.WriteValueFrom([]byte("banana"), strings.NewReader(large), int64(len(large))); err != nil {
+		fmt.Println(err)
+	}
+
+	_ = w.Write(Entry{Key: []byte("cherry"), Value: []byte("red")})
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	s, err := NewSSTable(bytes.NewReader(b))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	r, length, found, err := s.OpenValue([]byte("banana"))
+	value, _ := io.ReadAll(r)
+	fmt.Println(length, found, err, string(value) == large)
+
+	value, _, _ = s.Get([]byte("banana"))
+	fmt.Println(string(value) == large)
+
+	values, err := s.GetAll([]byte("banana"))
+	fmt.Println(len(values), string(values[0]) == large, err)
+
+	// Cursors read the large values too, also from readers that aren't
+	// random access.
+	for key, value := range s.All() {
+		fmt.Printf("%s %d\n", key, len(value))
+	}
+
+	stream, _ := NewSSTable(io.MultiReader(bytes.NewReader(b)))
+	for e, err := range stream.Entries(nil, nil) {
+		fmt.Printf("%s %d %v\n", e.Key, len(e.Value), err)
+	}
+
+	// A corrupted value fails the checksum at the end of the value.
+	b[bytes.Index(b, []byte("yellow"))] ^= 0x20
+	s, _ = NewSSTable(bytes.NewReader(b))
+	r, _, _, _ = s.OpenValue([]byte("banana"))
+	_, err = io.ReadAll(r)
+	fmt.Println(err)
+
+	_, err = s.GetAll([]byte("banana"))
+	fmt.Println(err)
+	// Output:
+	// 6000 true <nil> true
+	// true
+	// 1 true <nil>
+	// apple 3
+	// banana 6000
+	// cherry 3
+	// apple 3 <nil>
+	// banana 6000 <nil>
+	// cherry 3 <nil>
+	// sstable: checksum mismatch in value at offset 29
+	// sstable: checksum mismatch in value at offset 29
+}
+
+func ExampleWriter_WriteValueFrom_keepLast() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	// The replaced values still take space in the table, but a cursor
+	// drops them as soon as the next value arrives.
+	w := NewWriter(f, WithLargeValues(), WithDuplicates(KeepLast))
+	for _, v := range []string{"red", "green", "yellow"} {
+		_ = w.WriteValueFrom([]byte("apple"), strings.NewReader(v), int64(len(v)))
+	}
+
+	_ = w.WriteValueFrom([]byte("banana"), strings.NewReader("yellow"), 6)
+	_ = w.Write(Entry{Key: []byte("cherry"), Value: []byte("red")})
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	stream, _ := NewSSTable(io.MultiReader(bytes.NewReader(b)))
+	for e, err := range stream.Entries(nil, nil) {
+		fmt.Printf("%s %s %v\n", e.Key, e.Value, err)
+	}
+
+	stream, _ = NewSSTable(io.MultiReader(bytes.NewReader(b)), WithReadLimits(ReadLimits{MaxValueSize: 5}))
+	for _, err := range stream.Entries(nil, nil) {
+		fmt.Println(err)
+	}
+	// Output:
+	// apple yellow <nil>
+	// banana yellow <nil>
+	// cherry red <nil>
+	// sstable: corrupt table: value of 6 bytes exceeds the limit of 5
+}
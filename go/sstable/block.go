@@ -0,0 +1,387 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// block is the raw bytes of a data block. It is a sequence of
+// marshaled entries.
+type block []byte
+
+// blockHeaderSize is the number of bytes of the header of a framed
+// block: the payload length and the codec ID.
+const blockHeaderSize = 5
+
+// endOfBlocks is the codec ID of the block header that ends the data
+// blocks of footer-based tables. It is not a real codec.
+const endOfBlocks CodecID = 0xff
+
+// Encodings of the raw data blocks of format version 7 and later.
+const (
+	// plainEncoding stores the marshaled entries.
+	plainEncoding byte = 0
+	// prefixEncoding stores the entries prefix compressed.
+	prefixEncoding byte = 1
+	// blobEncoding stores the entry of a value that is stored out of
+	// line.
+	blobEncoding byte = 2
+)
+
+// errEndOfBlocks is returned when reading the block header that ends
+// the data blocks.
+var errEndOfBlocks = errors.New("end of blocks")
+
+// blockSlicer is implemented by readers that can return the bytes of
+// a block without copying them.
+type blockSlicer interface {
+	slice(offset, length uint64) ([]byte, error)
+}
+
+// readBlock reads the data block described by e from r.
+func readBlock(r io.ReaderAt, e indexEntry) (block, error) {
+	b, err := readFullAt(r, e.blockOffset, uint64(e.blockLength))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return b, err
+}
+
+// blockFormat describes how the data blocks are framed.
+type blockFormat struct {
+	// framed is true if the blocks have a header with the codec.
+	framed bool
+	// checksummed is true if the framed blocks end with a checksum.
+	checksummed bool
+	// terminated is true if the data blocks end with a block header
+	// of endOfBlocks.
+	terminated bool
+	// encoded is true if the raw blocks start with their encoding.
+	encoded bool
+}
+
+// blockFormatOf returns the block format of the version.
+func blockFormatOf(version uint32) blockFormat {
+	return blockFormat{
+		framed:      version >= versionCompression,
+		checksummed: version >= versionChecksums,
+		terminated:  version >= versionFooter,
+		encoded:     version >= versionPrefixKeys,
+	}
+}
+
+// terminator returns the block header that ends the data blocks.
+func (f blockFormat) terminator() []byte {
+	if !f.terminated {
+		return nil
+	}
+
+	return []byte{0, 0, 0, 0, byte(endOfBlocks)}
+}
+
+// trailerSize returns the number of bytes that follow the payload.
+func (f blockFormat) trailerSize() int {
+	if f.checksummed {
+		return checksumSize
+	}
+
+	return 0
+}
+
+// encodeBlock compresses the raw block with the codec and frames it.
+// It stores the block uncompressed if compression doesn't make it
+// smaller.
+func (f blockFormat) encodeBlock(raw []byte, id CodecID) ([]byte, error) {
+	if !f.framed {
+		return raw, nil
+	}
+
+	c, err := lookupCodec(id)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := c.Encode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress the block: %w", err)
+	}
+
+	if len(payload) >= len(raw) {
+		payload, id = raw, NoCompression
+	}
+
+	if len(payload) > math.MaxUint32-blockHeaderSize-f.trailerSize() {
+		return nil, errors.New("encodeBlock: block too large")
+	}
+
+	framed := make([]byte, blockHeaderSize, blockHeaderSize+len(payload)+f.trailerSize())
+	binary.BigEndian.PutUint32(framed[:4], uint32(len(payload))) //nolint:gosec // overflow checked above
+	framed[4] = byte(id)
+	framed = append(framed, payload...)
+
+	if f.checksummed {
+		framed = binary.BigEndian.AppendUint32(framed, checksum(framed))
+	}
+
+	return framed, nil
+}
+
+// decodeBlock returns the raw block of the block at offset as stored in
+// the file within the limits. It verifies the checksum if verify is
+// true.
+func (f blockFormat) decodeBlock(stored []byte, offset uint64, verify bool, limits ReadLimits) (block, error) {
+	if !f.framed {
+		return stored, nil
+	}
+
+	if len(stored) < blockHeaderSize+f.trailerSize() {
+		return nil, fmt.Errorf("decodeBlock: truncated block header at offset %d: %w", offset, ErrCorrupt)
+	}
+
+	framed := stored[:len(stored)-f.trailerSize()]
+	if f.checksummed && verify {
+		if binary.BigEndian.Uint32(stored[len(framed):]) != checksum(framed) {
+			return nil, &CorruptionError{Offset: offset, What: "data block"}
+		}
+	}
+
+	payload := framed[blockHeaderSize:]
+	if uint64(binary.BigEndian.Uint32(framed[:4])) != uint64(len(payload)) {
+		return nil, fmt.Errorf("decodeBlock: payload length mismatch at offset %d: %w", offset, ErrCorrupt)
+	}
+
+	c, err := lookupCodec(CodecID(framed[4]))
+	if err != nil {
+		return nil, fmt.Errorf("decodeBlock: block at offset %d: %w: %w", offset, err, ErrCorrupt)
+	}
+
+	raw, err := decode(c, payload, limits)
+	if err != nil && !errors.Is(err, ErrCorrupt) {
+		err = fmt.Errorf("%w: %w", err, ErrCorrupt)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the block at offset %d: %w", offset, err)
+	}
+
+	return raw, nil
+}
+
+// readBlockFrom reads the next framed block at offset from r within the
+// limits. It skips blob frames. It returns the raw block and the number
+// of bytes read.
+func (f blockFormat) readBlockFrom(r io.Reader, offset uint64, verify bool, limits ReadLimits) (block, uint64, error) {
+	var h [blockHeaderSize]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, 0, err
+	}
+
+	if f.terminated && CodecID(h[4]) == endOfBlocks {
+		return nil, blockHeaderSize, errEndOfBlocks
+	}
+
+	if f.terminated && CodecID(h[4]) == blobFrame {
+		n, err := f.skipBlob(r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		b, m, err := f.readBlockFrom(r, offset+n, verify, limits)
+
+		return b, n + m, err
+	}
+
+	length := blockHeaderSize + uint64(binary.BigEndian.Uint32(h[:4])) + uint64(f.trailerSize())
+	if err := limits.checkBlock(length); err != nil {
+		return nil, 0, err
+	}
+
+	rest, err := readFull(r, length-blockHeaderSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stored := append(h[:], rest...)
+	b, err := f.decodeBlock(stored, offset, verify, limits)
+
+	return b, uint64(len(stored)), err
+}
+
+// encoding returns the encoding of the raw block and the rest of it.
+func (f blockFormat) encoding(b block) (byte, block, error) {
+	if !f.encoded {
+		return plainEncoding, b, nil
+	}
+
+	if len(b) == 0 {
+		return 0, nil, fmt.Errorf("blockFormat.encoding: empty block: %w", ErrCorrupt)
+	}
+
+	switch b[0] {
+	case plainEncoding, prefixEncoding, blobEncoding:
+		return b[0], b[1:], nil
+	default:
+		return 0, nil, fmt.Errorf("blockFormat.encoding: unknown encoding %d: %w", b[0], ErrCorrupt)
+	}
+}
+
+// entries decodes all the entries of the raw block within the limits and
+// appends them to dst. The values, and the keys unless the block is
+// prefix compressed, point into the block.
+func (f blockFormat) entries(b block, dst []*Entry, limits ReadLimits) ([]*Entry, error) {
+	encoding, b, err := f.encoding(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch encoding {
+	case prefixEncoding:
+		pb, err := parsePrefixBlock(b, limits)
+		if err != nil {
+			return nil, err
+		}
+
+		return pb.entries(dst)
+	case blobEncoding:
+		// The value is left out; SSTable.OpenValue reads it.
+		e, ref, err := unmarshalBlobBlock(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := limits.checkEntry(uint64(len(e.Key)), ref.length); err != nil {
+			return nil, err
+		}
+
+		return append(dst, e), nil
+	}
+
+	for offset := 0; offset < len(b); {
+		e, next, err := b.entryAt(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := limits.checkEntry(uint64(len(e.Key)), uint64(len(e.Value))); err != nil {
+			return nil, err
+		}
+
+		dst = append(dst, e)
+		offset = next
+	}
+
+	return dst, nil
+}
+
+// find returns the first entry of the key in the raw block in the order
+// of cmp within the limits. It returns nil if the key isn't in the
+// block. If the value is stored out of line, the entry has no value and
+// find returns the blobRef of the value.
+func (f blockFormat) find(b block, key []byte, cmp Comparer, limits ReadLimits) (*Entry, *blobRef, error) {
+	encoding, b, err := f.encoding(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch encoding {
+	case prefixEncoding:
+		pb, err := parsePrefixBlock(b, limits)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		e, err := pb.find(key, cmp)
+
+		return e, nil, err
+	case blobEncoding:
+		e, ref, err := unmarshalBlobBlock(b)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := limits.checkEntry(uint64(len(e.Key)), ref.length); err != nil {
+			return nil, nil, err
+		}
+
+		if cmp.Compare(e.Key, key) != 0 {
+			return nil, nil, nil
+		}
+
+		return e, ref, nil
+	}
+
+	for offset := 0; offset < len(b); {
+		e, next, err := b.entryAt(offset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := limits.checkEntry(uint64(len(e.Key)), uint64(len(e.Value))); err != nil {
+			return nil, nil, err
+		}
+
+		switch c := cmp.Compare(e.Key, key); {
+		case c == 0:
+			return e, nil, nil
+		case c > 0:
+			return nil, nil, nil
+		}
+
+		offset = next
+	}
+
+	return nil, nil, nil
+}
+
+// skipBlob skips the rest of the blob frame whose block header was read
+// from r. It returns the number of bytes of the frame.
+func (f blockFormat) skipBlob(r io.Reader) (uint64, error) {
+	var length [8]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return 0, err
+	}
+
+	n := binary.BigEndian.Uint64(length[:]) + uint64(f.trailerSize())
+	if n > math.MaxInt64 {
+		return 0, fmt.Errorf("blockFormat.skipBlob: value length %w: %d", ErrOffsetOverflow, n)
+	}
+
+	if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil { //nolint:gosec // overflow checked above
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return 0, err
+	}
+
+	return blobFrameSize + n, nil
+}
+
+// entryAt decodes the entry at offset of the block. It returns the
+// entry and the offset of the next entry. The key and the value of the
+// entry point into the block.
+func (b block) entryAt(offset int) (*Entry, int, error) {
+	if offset < 0 || len(b)-offset < 8 {
+		return nil, 0, fmt.Errorf("block.entryAt: truncated entry at %d: %w", offset, ErrCorrupt)
+	}
+
+	keyLength := uint64(binary.BigEndian.Uint32(b[offset : offset+4]))
+	valueLength := uint64(binary.BigEndian.Uint32(b[offset+4 : offset+8]))
+
+	if uint64(len(b)-offset-8) < keyLength+valueLength {
+		return nil, 0, fmt.Errorf("block.entryAt: truncated entry at %d: %w", offset, ErrCorrupt)
+	}
+
+	keyStart := offset + 8
+	valueStart := keyStart + int(keyLength) //nolint:gosec // bounded by len(b) above
+	next := valueStart + int(valueLength)   //nolint:gosec // bounded by len(b) above
+
+	return &Entry{
+		Key:   b[keyStart:valueStart:valueStart],
+		Value: b[valueStart:next:next],
+	}, next, nil
+}
@@ -0,0 +1,134 @@
+package sstable
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// BlockCache is an LRU cache of data blocks with a budget in bytes.
+// Multiple SSTables can share a BlockCache, and it is safe for
+// concurrent use.
+type BlockCache struct {
+	mu       sync.Mutex
+	capacity int64
+	size     int64
+	lru      *list.List
+	blocks   map[blockCacheKey]*list.Element
+	hits     uint64
+	misses   uint64
+	nextID   atomic.Uint64
+}
+
+// blockCacheKey identifies a block by the table and the block offset.
+type blockCacheKey struct {
+	table  uint64
+	offset uint64
+}
+
+// blockCacheEntry is the value of the LRU list.
+type blockCacheEntry struct {
+	key   blockCacheKey
+	block block
+}
+
+// CacheStats is a snapshot of the counters of a BlockCache.
+type CacheStats struct {
+	Hits     uint64
+	Misses   uint64
+	Blocks   int
+	Size     int64
+	Capacity int64
+}
+
+// NewBlockCache creates a BlockCache that holds up to capacity bytes
+// of blocks.
+func NewBlockCache(capacity int64) *BlockCache {
+	return &BlockCache{
+		capacity: capacity,
+		lru:      list.New(),
+		blocks:   map[blockCacheKey]*list.Element{},
+	}
+}
+
+// newTableID returns an identity for a table that uses the cache.
+func (c *BlockCache) newTableID() uint64 {
+	return c.nextID.Add(1)
+}
+
+// get returns the cached block and marks it as recently used.
+func (c *BlockCache) get(key blockCacheKey) (block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.blocks[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(elem)
+
+	return elem.Value.(*blockCacheEntry).block, true
+}
+
+// add caches the block and evicts the least recently used blocks to
+// stay within the capacity. A block larger than the capacity is not
+// cached.
+func (c *BlockCache) add(key blockCacheKey, b block) {
+	size := int64(len(b))
+	if size > c.capacity {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.blocks[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.blocks[key] = c.lru.PushFront(&blockCacheEntry{key: key, block: b})
+	c.size += size
+
+	for c.size > c.capacity {
+		oldest := c.lru.Back()
+		e := oldest.Value.(*blockCacheEntry)
+
+		c.lru.Remove(oldest)
+		delete(c.blocks, e.key)
+		c.size -= int64(len(e.block))
+	}
+}
+
+// drop removes the blocks of the table from the cache.
+func (c *BlockCache) drop(table uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.blocks {
+		if key.table != table {
+			continue
+		}
+
+		c.lru.Remove(elem)
+		delete(c.blocks, key)
+		c.size -= int64(len(elem.Value.(*blockCacheEntry).block))
+	}
+}
+
+// Stats returns the counters of the cache.
+func (c *BlockCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Hits:     c.hits,
+		Misses:   c.misses,
+		Blocks:   c.lru.Len(),
+		Size:     c.size,
+		Capacity: c.capacity,
+	}
+}
@@ -0,0 +1,65 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func ExampleBlockCache() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	// Each value is large enough to fill a block of its own.
+	w := NewWriter(f)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := w.Write(Entry{Key: []byte(key), Value: bytes.Repeat([]byte(key), 40000)}); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// The cache can hold two of the blocks.
+	cache := NewBlockCache(100000)
+	r1 := &countingReaderAt{Reader: bytes.NewReader(b)}
+	s1, _ := NewSSTable(r1, WithBlockCache(cache))
+	r2 := &countingReaderAt{Reader: bytes.NewReader(b)}
+	s2, _ := NewSSTable(r2, WithBlockCache(cache))
+
+	r1.reads, r2.reads = 0, 0
+
+	for _, key := range []string{"a", "a", "b", "a"} {
+		s1.Get([]byte(key))
+	}
+
+	fmt.Println(r1.reads, r2.reads)
+	fmt.Printf("%+v\n", cache.Stats())
+
+	// The tables don't share blocks, and caching the block of s2 evicts
+	// "b" of s1, the least recently used block.
+	s2.Get([]byte("a"))
+	s1.Get([]byte("b"))
+	fmt.Println(r1.reads, r2.reads)
+	fmt.Printf("%+v\n", cache.Stats())
+
+	// Closing a table drops its blocks.
+	s1.Close()
+	fmt.Printf("%+v\n", cache.Stats())
+
+	// A nil cache means no cache.
+	s3, _ := NewSSTable(bytes.NewReader(b), WithBlockCache(nil))
+	value, found, err := s3.Get([]byte("c"))
+	fmt.Println(len(value), found, err)
+	// Output:
+	// 2 0
+	// {Hits:2 Misses:2 Blocks:2 Size:80018 Capacity:100000}
+	// 3 1
+	// {Hits:2 Misses:4 Blocks:2 Size:80018 Capacity:100000}
+	// {Hits:2 Misses:4 Blocks:1 Size:40009 Capacity:100000}
+	// 40000 true <nil>
+}
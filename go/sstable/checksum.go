@@ -0,0 +1,87 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// checksumSize is the number of bytes of a checksum.
+const checksumSize = 4
+
+// crcTable is the CRC32C (Castagnoli) table of the checksums.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// CorruptionError reports data that failed an integrity check.
+type CorruptionError struct {
+	// Offset is the offset of the corrupt data in the file.
+	Offset uint64
+	// What describes the corrupt data, such as "data block".
+	What string
+}
+
+// Error implements the error interface.
+func (e *CorruptionError) Error() string {
+	return fmt.Sprintf("sstable: checksum mismatch in %s at offset %d", e.What, e.Offset)
+}
+
+// Is makes every CorruptionError match ErrCorrupt.
+func (e *CorruptionError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+// checksum returns the CRC32C checksum of data.
+func checksum(data []byte) uint32 {
+	return crc32.Checksum(data, crcTable)
+}
+
+// checksumWriter writes to the underlying writer and computes the
+// checksum of the written bytes.
+type checksumWriter struct {
+	w   io.Writer
+	crc uint32
+}
+
+// Write implements the io.Writer interface.
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.crc = crc32.Update(c.crc, crcTable, p[:n])
+
+	return n, err
+}
+
+// writeChecksum writes the checksum of the written bytes.
+func (c *checksumWriter) writeChecksum() error {
+	var buf [checksumSize]byte
+	binary.BigEndian.PutUint32(buf[:], c.crc)
+	_, err := c.w.Write(buf[:])
+
+	return err
+}
+
+// checksumReader reads from the underlying reader and computes the
+// checksum of the read bytes.
+type checksumReader struct {
+	r   io.Reader
+	crc uint32
+}
+
+// Read implements the io.Reader interface.
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc = crc32.Update(c.crc, crcTable, p[:n])
+
+	return n, err
+}
+
+// verifyChecksum reads the checksum that follows the read bytes and
+// compares it with the computed one.
+func (c *checksumReader) verifyChecksum() (bool, error) {
+	var buf [checksumSize]byte
+	if _, err := io.ReadFull(c.r, buf[:]); err != nil {
+		return false, err
+	}
+
+	return binary.BigEndian.Uint32(buf[:]) == c.crc, nil
+}
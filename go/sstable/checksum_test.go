@@ -0,0 +1,65 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func ExampleWithChecksums() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithChecksums())
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// Flip a bit of the value "yellow" in the data block.
+	i := bytes.Index(b, []byte("yellow"))
+	b[i] ^= 0x20
+
+	s, err := NewSSTable(bytes.NewReader(b))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	_, _, err = s.Get([]byte("banana"))
+
+	var corruption *CorruptionError
+	fmt.Println(errors.As(err, &corruption), err)
+
+	c := s.ScanRange(nil, nil, ScanOptions{})
+	for ; !c.Done(); c.Next() {
+		fmt.Printf("%s ", c.Entry().Key)
+	}
+
+	fmt.Println(c.Err())
+
+	// Bulk scans can skip the verification.
+	c = s.ScanRange([]byte("banana"), nil, ScanOptions{SkipChecksums: true, Limit: 1})
+	for ; !c.Done(); c.Next() {
+		fmt.Printf("%s %s\n", c.Entry().Key, c.Entry().Value)
+	}
+
+	// The index is verified when the SSTable is opened.
+	b[i] ^= 0x20
+	b[99+4] ^= 0x01 // The index starts at offset 99.
+	_, err = NewSSTable(bytes.NewReader(b))
+	fmt.Println(err)
+	// Output:
+	// true sstable: checksum mismatch in data block at offset 16
+	// sstable: checksum mismatch in data block at offset 16
+	// banana Yellow
+	// sstable: checksum mismatch in index at offset 99
+}
@@ -0,0 +1,208 @@
+package sstable
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// CodecID identifies a compression codec in the SSTable file.
+type CodecID uint8
+
+// Codecs that are registered by default.
+const (
+	// NoCompression stores blocks as they are.
+	NoCompression CodecID = 0
+	// FlateCompression compresses blocks with DEFLATE.
+	FlateCompression CodecID = 1
+	// SnappyCompression compresses blocks with the Snappy block format.
+	SnappyCompression CodecID = 2
+)
+
+// Codec compresses and decompresses data blocks.
+type Codec interface {
+	// Encode returns the compressed src.
+	Encode(src []byte) ([]byte, error)
+	// Decode returns the decompressed src.
+	Decode(src []byte) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[CodecID]Codec{
+		NoCompression:     noCodec{},
+		FlateCompression:  flateCodec{},
+		SnappyCompression: snappyCodec{},
+	}
+)
+
+// RegisterCodec makes a codec available by the id to Writers and
+// SSTables. It panics if the id is already registered or reserved, or
+// the codec is nil. The ids 0xfe and 0xff are reserved.
+func RegisterCodec(id CodecID, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	if c == nil {
+		panic("sstable: RegisterCodec codec is nil")
+	}
+
+	if id == endOfBlocks || id == blobFrame {
+		panic(fmt.Sprintf("sstable: RegisterCodec called with reserved codec %d", id))
+	}
+
+	if _, dup := codecs[id]; dup {
+		panic(fmt.Sprintf("sstable: RegisterCodec called twice for codec %d", id))
+	}
+
+	codecs[id] = c
+}
+
+// lookupCodec returns the codec registered by the id.
+func lookupCodec(id CodecID) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %d", id)
+	}
+
+	return c, nil
+}
+
+// isCodec reports whether a codec is registered by the id.
+func isCodec(id CodecID) bool {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	_, ok := codecs[id]
+
+	return ok
+}
+
+// noCodec stores data as it is.
+type noCodec struct{}
+
+// Encode implements the Codec interface.
+func (noCodec) Encode(src []byte) ([]byte, error) {
+	return src, nil
+}
+
+// Decode implements the Codec interface.
+func (noCodec) Decode(src []byte) ([]byte, error) {
+	return src, nil
+}
+
+// flateCodec compresses data with DEFLATE.
+type flateCodec struct{}
+
+// Encode implements the Codec interface.
+func (flateCodec) Encode(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fw.Write(src); err != nil {
+		return nil, err
+	}
+
+	if err := fw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decode implements the Codec interface.
+func (flateCodec) Decode(src []byte) ([]byte, error) {
+	fr := flate.NewReader(bytes.NewReader(src))
+	defer fr.Close()
+
+	return io.ReadAll(fr)
+}
+
+// decodeLimited implements the limitedDecoder interface.
+func (flateCodec) decodeLimited(src []byte, limits ReadLimits) ([]byte, error) {
+	fr := flate.NewReader(bytes.NewReader(src))
+	defer fr.Close()
+
+	// Reading a byte beyond the limit tells a block that exceeds it from
+	// a block of exactly the limit.
+	n := int64(min(limits.MaxBlockSize, math.MaxInt64-1)) + 1 //nolint:gosec // bounded by min
+
+	b, err := io.ReadAll(io.LimitReader(fr, n))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := limits.checkBlock(uint64(len(b))); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// snappyCodec compresses data with the Snappy block format.
+type snappyCodec struct{}
+
+// Encode implements the Codec interface.
+func (snappyCodec) Encode(src []byte) ([]byte, error) {
+	return snappyEncode(src), nil
+}
+
+// Decode implements the Codec interface.
+func (snappyCodec) Decode(src []byte) ([]byte, error) {
+	return snappyDecode(src)
+}
+
+// decodeLimited implements the limitedDecoder interface. The Snappy
+// block format starts with the decoded length, so it is checked before
+// anything is decoded.
+func (snappyCodec) decodeLimited(src []byte, limits ReadLimits) ([]byte, error) {
+	if length, n := binary.Uvarint(src); n > 0 {
+		if err := limits.checkBlock(length); err != nil {
+			return nil, err
+		}
+	}
+
+	return snappyDecode(src)
+}
+
+// limitedDecoder is implemented by the codecs that stop decoding once
+// the decoded data exceeds the limits, instead of decoding all of it
+// first.
+type limitedDecoder interface {
+	decodeLimited(src []byte, limits ReadLimits) ([]byte, error)
+}
+
+// decode decodes src with the codec within the limits. The decoded
+// block must not exceed MaxBlockSize either. The codecs that aren't
+// limitedDecoders are checked once they have decoded the whole block.
+func decode(c Codec, src []byte, limits ReadLimits) ([]byte, error) {
+	if limits.MaxBlockSize == 0 {
+		return c.Decode(src)
+	}
+
+	if d, ok := c.(limitedDecoder); ok {
+		return d.decodeLimited(src, limits)
+	}
+
+	b, err := c.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := limits.checkBlock(uint64(len(b))); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
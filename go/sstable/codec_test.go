@@ -0,0 +1,119 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func ExampleWithCompression() {
+	for _, id := range []CodecID{NoCompression, FlateCompression, SnappyCompression} {
+		f, _ := os.CreateTemp("", "")
+
+		name := f.Name()
+		defer os.Remove(name)
+
+		w := NewWriter(f, WithCompression(id))
+		for i := 0; i < 1000; i++ {
+			e := Entry{
+				Key:   []byte(fmt.Sprintf("key%04d", i)),
+				Value: []byte(strings.Repeat("mostly text values ", 10)),
+			}
+			if err := w.Write(e); err != nil {
+				fmt.Println(err)
+			}
+		}
+
+		w.Close()
+
+		b, _ := os.ReadFile(name)
+		s, _ := NewSSTable(bytes.NewReader(b))
+
+		value, found, err := s.Get([]byte("key0500"))
+		fmt.Println(id, len(b) < 50000, len(value), found, err)
+
+		// Readers that aren't random access read the blocks in order.
+		s, _ = NewSSTable(bytes.NewBuffer(b))
+
+		n := 0
+		for range s.All() {
+			n++
+		}
+
+		fmt.Println(n)
+	}
+	// Output:
+	// 0 false 190 true <nil>
+	// 1000
+	// 1 true 190 true <nil>
+	// 1000
+	// 2 true 190 true <nil>
+	// 1000
+}
+
+//nolint:govet
+func Example_snappy() {
+	src := []byte(strings.Repeat("abcdefgh", 100) + "the end")
+	encoded := snappyEncode(src)
+	decoded, err := snappyDecode(encoded)
+	fmt.Println(len(encoded), bytes.Equal(src, decoded), err)
+
+	_, err = snappyDecode(encoded[:len(encoded)-1])
+	fmt.Println(err)
+	// Output:
+	// 58 true <nil>
+	// snappy: corrupt input: sstable: corrupt table
+}
+
+// Example_snappyVectors decodes blocks built by hand from the Snappy
+// block format description: a varint of the length followed by literal
+// and copy elements.
+func Example_snappyVectors() {
+	vectors := []string{
+		// The empty input.
+		"\x00",
+		// A literal of 5 bytes, whose length-1 is in the tag.
+		"\x05\x10hello",
+		// A literal of 61 bytes, whose length-1 is in 1 extra byte.
+		"\x3d\xf0\x3c" + strings.Repeat("x", 61),
+		// A literal of 300 bytes, whose length-1 is in 2 extra bytes
+		// and whose length is a varint of 2 bytes.
+		"\xac\x02\xf4\x2b\x01" + strings.Repeat("y", 300),
+		// A copy with a 1-byte offset: 8 bytes from 4 back.
+		"\x0c\x0cabcd\x11\x04",
+		// A copy with a 2-byte offset that overlaps its output.
+		"\x08\x04ab\x16\x02\x00",
+		// A copy with a 4-byte offset.
+		"\x06\x04ab\x0f\x02\x00\x00\x00",
+	}
+
+	for _, v := range vectors {
+		decoded, err := snappyDecode([]byte(v))
+		fmt.Println(len(decoded), strings.Trim(string(decoded), "xy"), err)
+	}
+
+	// The encoder writes the same elements.
+	fmt.Printf("%q\n", snappyEncode([]byte("hello")))
+	fmt.Printf("%q\n", snappyEncode([]byte("abcdabcdabcd")))
+
+	// Copies may not reach before the start of the output, and the
+	// output must have the length.
+	for _, v := range []string{"\x08\x04ab\x16\x03\x00", "\x09\x04ab\x16\x02\x00"} {
+		_, err := snappyDecode([]byte(v))
+		fmt.Println(errors.Is(err, ErrCorrupt))
+	}
+	// Output:
+	// 0  <nil>
+	// 5 hello <nil>
+	// 61  <nil>
+	// 300  <nil>
+	// 12 abcdabcdabcd <nil>
+	// 8 abababab <nil>
+	// 6 ababab <nil>
+	// "\x05\x10hello"
+	// "\f\fabcd\x11\x04"
+	// true
+	// true
+}
@@ -0,0 +1,72 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// Comparer defines the order of the keys in a table.
+type Comparer interface {
+	// Compare returns -1, 0 or +1 depending on whether a is less than,
+	// equal to or greater than b.
+	Compare(a, b []byte) int
+	// Name identifies the order. The Writer records it in the table so
+	// that the table is never read in another order.
+	Name() string
+}
+
+// BytewiseComparer orders the keys lexicographically by bytes. It is
+// the order of tables that don't record a comparer.
+var BytewiseComparer Comparer = bytewiseComparer{}
+
+// bytewiseComparer implements BytewiseComparer.
+type bytewiseComparer struct{}
+
+// Compare implements the Comparer interface.
+func (bytewiseComparer) Compare(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+// Name implements the Comparer interface.
+func (bytewiseComparer) Name() string {
+	return "sstable.bytewise"
+}
+
+var (
+	comparersMu sync.RWMutex
+	comparers   = map[string]Comparer{
+		BytewiseComparer.Name(): BytewiseComparer,
+	}
+)
+
+// RegisterComparer makes a comparer available by its name to SSTables
+// that are opened without ExpectComparer. It panics if the name is
+// already registered or the comparer is nil.
+func RegisterComparer(c Comparer) {
+	comparersMu.Lock()
+	defer comparersMu.Unlock()
+
+	if c == nil {
+		panic("sstable: RegisterComparer comparer is nil")
+	}
+
+	if _, dup := comparers[c.Name()]; dup {
+		panic(fmt.Sprintf("sstable: RegisterComparer called twice for comparer %q", c.Name()))
+	}
+
+	comparers[c.Name()] = c
+}
+
+// lookupComparer returns the comparer registered by the name.
+func lookupComparer(name string) (Comparer, error) {
+	comparersMu.RLock()
+	defer comparersMu.RUnlock()
+
+	c, ok := comparers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown comparer %q", name)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,74 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// reverseComparer orders the keys in reverse bytewise order.
+type reverseComparer struct{}
+
+func (reverseComparer) Compare(a, b []byte) int {
+	return bytes.Compare(b, a)
+}
+
+func (reverseComparer) Name() string {
+	return "example.reverse"
+}
+
+func ExampleWithComparer() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithComparer(reverseComparer{}))
+	for i := len(exampleFruits) - 1; i >= 0; i-- {
+		if err := w.Write(exampleFruits[i]); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	fmt.Println(w.Write(Entry{Key: []byte("zucchini")}))
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// The comparer isn't registered.
+	_, err := NewSSTable(bytes.NewReader(b))
+	fmt.Println(err)
+
+	_, err = NewSSTable(bytes.NewReader(b), ExpectComparer(BytewiseComparer))
+	fmt.Println(err)
+
+	s, err := NewSSTable(bytes.NewReader(b), ExpectComparer(reverseComparer{}))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	value, found, err := s.Get([]byte("banana"))
+	fmt.Println(string(value), found, err)
+
+	var keys [][]byte
+	for key := range s.Range([]byte("banana"), nil) {
+		keys = append(keys, key)
+	}
+
+	fmt.Printf("%s\n", keys)
+
+	keys = nil
+	for key := range s.Prefix([]byte("ap")) {
+		keys = append(keys, key)
+	}
+
+	fmt.Printf("%s\n", keys)
+	// Output:
+	// key is not sorted
+	// failed to open the table: unknown comparer "example.reverse"
+	// NewSSTable: table is ordered by comparer "example.reverse", not "sstable.bytewise"
+	// yellow true <nil>
+	// [banana apricot apple]
+	// [apricot apple]
+}
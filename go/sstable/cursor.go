@@ -1,6 +1,8 @@
 package sstable
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 )
 
@@ -9,51 +11,70 @@ type Cursor interface {
 	Entry() *Entry
 	Done() bool
 	Next()
+	// Err returns the first error that the cursor encountered. A
+	// cursor that fails is done, so callers should check Err after
+	// Done returns true to tell a complete scan from a partial one.
+	Err() error
 }
 
 // CursorToOffset is a Cursor that read until the endOffset.
 type CursorToOffset struct {
 	reader    interface{}
+	limits    ReadLimits
 	offset    uint64
 	endOffset uint64
 	entry     *Entry
+	err       error
 }
 
-// Entry returns the current entry.
+// Entry returns the current entry. It returns nil if the cursor is
+// done.
 func (c *CursorToOffset) Entry() *Entry {
-	if c.entry != nil {
-		return c.entry
+	if c.entry == nil && c.err == nil && c.offset < c.endOffset {
+		c.read()
 	}
 
+	return c.entry
+}
+
+// read reads the entry at the current offset.
+func (c *CursorToOffset) read() {
+	var (
+		e   *Entry
+		err error
+	)
+
 	switch r := c.reader.(type) {
 	case io.ReaderAt:
-		e, err := ReadEntryAt(r, c.offset)
-		if err != nil {
-			return nil
-		}
+		e, err = readEntryAt(r, c.offset, c.limits)
+	case io.Reader:
+		e, err = readEntry(r, c.limits)
+	default:
+		err = fmt.Errorf("CursorToOffset.Entry: %T is not a reader: %w", r, ErrNotRandomAccess)
+	}
 
-		c.offset += e.Size()
-		c.entry = e
+	if err == nil && e == nil {
+		err = io.ErrUnexpectedEOF
+	}
 
-		return c.entry
-	case io.Reader:
-		e, err := ReadEntry(r)
-		if err != nil {
-			return nil
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
 		}
 
-		c.offset += e.Size()
-		c.entry = e
+		c.err = err
 
-		return c.entry
-	default:
-		panic("unimplemented")
+		return
 	}
+
+	c.offset += e.Size()
+	c.entry = e
 }
 
-// Done returns true when there is no more entry to read.
+// Done returns true when there is no more entry to read or the cursor
+// failed to read the next entry.
 func (c *CursorToOffset) Done() bool {
-	if c.entry == nil && c.offset >= c.endOffset {
+	if c.Entry() == nil {
 		c.reader = nil
 		return true
 	}
@@ -69,3 +90,194 @@ func (c *CursorToOffset) Next() {
 
 	c.entry = nil
 }
+
+// Err returns the first error that the cursor encountered.
+func (c *CursorToOffset) Err() error {
+	return c.err
+}
+
+// rangeCursor is a Cursor that is done at an upper bound key or after
+// a number of entries. It skips the keys without the prefix if any.
+type rangeCursor struct {
+	Cursor
+	cmp          Comparer
+	prefix       []byte
+	end          []byte
+	endInclusive bool
+	limit        int
+	count        int
+}
+
+// Entry returns the current entry. It returns nil if the cursor is
+// done.
+func (c *rangeCursor) Entry() *Entry {
+	if c.Done() {
+		return nil
+	}
+
+	return c.Cursor.Entry()
+}
+
+// Done returns true when the underlying cursor is done, the current key
+// is beyond the upper bound or the limit is reached.
+func (c *rangeCursor) Done() bool {
+	if c.limit > 0 && c.count >= c.limit {
+		return true
+	}
+
+	for !c.Cursor.Done() && !c.beyondEnd(c.Cursor.Entry().Key) {
+		if c.prefix == nil || bytes.HasPrefix(c.Cursor.Entry().Key, c.prefix) {
+			return false
+		}
+
+		c.Cursor.Next()
+	}
+
+	return true
+}
+
+// beyondEnd returns true if the key is beyond the upper bound.
+func (c *rangeCursor) beyondEnd(key []byte) bool {
+	if c.end == nil {
+		return false
+	}
+
+	cmp := c.cmp.Compare(key, c.end)
+
+	return cmp > 0 || cmp == 0 && !c.endInclusive
+}
+
+// Next moves the cursor to the next entry.
+func (c *rangeCursor) Next() {
+	if c.Done() {
+		return
+	}
+
+	c.count++
+	c.Cursor.Next()
+}
+
+// blockCursor is a Cursor that reads framed blocks from a reader that
+// isn't random access until the endOffset or the end of the blocks.
+type blockCursor struct {
+	reader    io.Reader
+	format    blockFormat
+	verify    bool
+	limits    ReadLimits
+	offset    uint64
+	endOffset uint64
+	entries   []*Entry
+	// blob is the value of the last blob frame, which was read before
+	// the block of its entry, and blobOffset is its offset.
+	blob       []byte
+	blobOffset uint64
+	err        error
+}
+
+// Entry returns the current entry. It returns nil if the cursor is
+// done.
+func (c *blockCursor) Entry() *Entry {
+	for len(c.entries) == 0 && c.err == nil && c.offset < c.endOffset {
+		c.read()
+	}
+
+	if len(c.entries) == 0 {
+		return nil
+	}
+
+	return c.entries[0]
+}
+
+// read reads the block at the current offset. It keeps the value of a
+// blob frame until the block of its entry. The Writer copies a value
+// only after the blocks of the values before it, so the value of a
+// blob frame that another one follows belongs to no entry, like a value
+// that KeepLast replaced, and is dropped. The cursor holds at most one
+// value at a time.
+func (c *blockCursor) read() {
+	var h [blockHeaderSize]byte
+
+	_, err := io.ReadFull(c.reader, h[:])
+	if err == nil && c.format.terminated && CodecID(h[4]) == blobFrame {
+		ref, value, n, err := c.format.readBlobFrame(c.reader, c.offset, c.verify, c.limits)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		c.blob, c.blobOffset = value, ref.offset
+		c.offset += n
+
+		return
+	}
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	b, n, err := c.format.readBlockFrom(io.MultiReader(bytes.NewReader(h[:]), c.reader), c.offset, c.verify, c.limits)
+	if err == errEndOfBlocks {
+		c.offset += n
+		c.endOffset = c.offset
+
+		return
+	}
+
+	if err == nil {
+		c.entries, err = c.format.entries(b, c.entries, c.limits)
+	}
+
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	for i, e := range c.entries {
+		c.entries[i] = e.clone()
+	}
+
+	ref, err := c.format.blobRef(b)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	if ref != nil {
+		if c.blob == nil || c.blobOffset != ref.offset || uint64(len(c.blob)) != ref.length {
+			c.fail(fmt.Errorf("%w: no value at offset %d before the block", ErrCorrupt, ref.offset))
+			return
+		}
+
+		c.entries[0].Value, c.blob = c.blob, nil
+	}
+
+	c.offset += n
+}
+
+// fail stops the cursor with the error.
+func (c *blockCursor) fail(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	c.entries, c.err = nil, err
+}
+
+// Done returns true when there is no more entry to read or the cursor
+// failed to read the next block.
+func (c *blockCursor) Done() bool {
+	return c.Entry() == nil
+}
+
+// Next moves the cursor to the next entry.
+func (c *blockCursor) Next() {
+	if c.Entry() != nil {
+		c.entries = c.entries[1:]
+	}
+}
+
+// Err returns the first error that the cursor encountered.
+func (c *blockCursor) Err() error {
+	return c.err
+}
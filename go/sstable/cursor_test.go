@@ -69,3 +69,27 @@ func ExampleCursorToOffset() {
 	// Key: key1, Value: value1
 	// Key: key2, Value: value22
 }
+
+func ExampleCursorToOffset_Err() {
+	entry := Entry{Key: []byte("key1"), Value: []byte("value1")}
+
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		fmt.Println("Error marshalling entry:", err)
+		return
+	}
+
+	// The second entry is cut off in the middle of its value.
+	data = append(data, data[:len(data)-2]...)
+
+	cursor := NewRecordIOReader(bytes.NewReader(data), uint64(len(data)))
+	for !cursor.Done() {
+		fmt.Printf("Key: %s\n", string(cursor.Entry().Key))
+		cursor.Next()
+	}
+
+	fmt.Println("Error:", cursor.Err())
+	// Output:
+	// Key: key1
+	// Error: unexpected EOF
+}
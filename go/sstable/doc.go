@@ -0,0 +1,15 @@
+// Package sstable reads and writes SSTables, files of entries sorted by
+// key with an index of their blocks.
+//
+// The Writer writes the oldest format version that holds its options.
+// Without options, that is format version 2, which has no checksums,
+// no footer and no magic bytes, so it detects neither corruption nor
+// files that aren't SSTables. Checksums are opt-in, also for compressed
+// tables: WithChecksums, or WithFormatVersion of 5 or later, makes the
+// Writer end the blocks, the index and the meta blocks with CRC32C
+// checksums, which readers verify. Format version 9 and later also end
+// the file with magic bytes; see ErrNotSSTable.
+//
+// Readers accept every format version up to the latest, and ReadLimits
+// bound what they allocate for a corrupt or hostile file.
+package sstable
@@ -0,0 +1,49 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func ExampleWithDuplicates() {
+	entries := []Entry{
+		{Key: []byte("apple"), Value: []byte("red")},
+		{Key: []byte("apple"), Value: []byte("green")},
+		{Key: []byte("apple"), Value: []byte("yellow")},
+		{Key: []byte("banana"), Value: []byte("yellow")},
+	}
+
+	for _, p := range []DuplicatePolicy{AllowDuplicates, RejectDuplicates, KeepFirst, KeepLast} {
+		f, _ := os.CreateTemp("", "")
+		defer os.Remove(f.Name())
+
+		// Every entry fills a block of its own.
+		w := NewWriter(f, WithDuplicates(p), WithBlockSize(1))
+		for _, e := range entries {
+			if err := w.Write(e); err != nil {
+				fmt.Println(err)
+			}
+		}
+
+		w.Close()
+
+		b, _ := os.ReadFile(f.Name())
+
+		s, err := NewSSTable(bytes.NewReader(b))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		values, err := s.GetAll([]byte("apple"))
+		fmt.Printf("%s %v\n", values, err)
+	}
+	// Output:
+	// [red green yellow] <nil>
+	// Writer.Write: duplicate key "apple"
+	// Writer.Write: duplicate key "apple"
+	// [red] <nil>
+	// [red] <nil>
+	// [yellow] <nil>
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 )
@@ -16,48 +17,76 @@ type Entry struct {
 	Value []byte
 }
 
-// ReadEntry reads an entry from r.
+// ReadEntry reads an entry from r. It allocates the memory for the
+// entry as the bytes arrive, so a corrupt length can't make it allocate
+// much more than r holds.
 func ReadEntry(r io.Reader) (*Entry, error) {
+	return readEntry(r, ReadLimits{})
+}
+
+// readEntry reads an entry from r within the limits.
+func readEntry(r io.Reader, limits ReadLimits) (*Entry, error) {
 	lenbuf := make([]byte, 8)
 	if _, err := io.ReadFull(r, lenbuf); err != nil {
 		return nil, err
 	}
 
-	keyLength := binary.BigEndian.Uint32(lenbuf[:4])
-	valueLength := binary.BigEndian.Uint32(lenbuf[4:8])
-	buf := make([]byte, 8+keyLength+valueLength)
-	copy(buf, lenbuf)
+	keyLength := uint64(binary.BigEndian.Uint32(lenbuf[:4]))
+	valueLength := uint64(binary.BigEndian.Uint32(lenbuf[4:8]))
+
+	if err := limits.checkEntry(keyLength, valueLength); err != nil {
+		return nil, err
+	}
 
-	if _, err := io.ReadFull(r, buf[8:]); err != nil {
+	data, err := readFull(r, keyLength+valueLength)
+	if err != nil {
 		return nil, err
 	}
 
-	var e Entry
-	return &e, e.UnmarshalBinary(buf) //nolint:wsl
+	return &Entry{Key: data[:keyLength:keyLength], Value: data[keyLength:]}, nil
 }
 
-// ReadEntryAt reads an entry from the offset of r.
+// ReadEntryAt reads an entry from the offset of r. It checks the
+// lengths of the entry against the size of r before it allocates the
+// memory for the entry, if r reports its size.
 func ReadEntryAt(r io.ReaderAt, offset uint64) (*Entry, error) {
+	return readEntryAt(r, offset, ReadLimits{})
+}
+
+// readEntryAt reads an entry from the offset of r within the limits.
+func readEntryAt(r io.ReaderAt, offset uint64, limits ReadLimits) (*Entry, error) {
 	var lenbuf [8]byte
 
-	if offset > math.MaxInt64 {
-		panic("unimplemented")
+	if offset > math.MaxInt64-8 {
+		return nil, fmt.Errorf("ReadEntryAt: %w: %d", ErrOffsetOverflow, offset)
 	}
 
 	if n, err := r.ReadAt(lenbuf[:], int64(offset)); n != len(lenbuf) { //nolint:gosec // overflow checked above
 		return nil, err
 	}
 
-	keyLength := binary.BigEndian.Uint32(lenbuf[:4])
-	valueLength := binary.BigEndian.Uint32(lenbuf[4:8])
-	buf := make([]byte, 8+keyLength+valueLength)
+	keyLength := uint64(binary.BigEndian.Uint32(lenbuf[:4]))
+	valueLength := uint64(binary.BigEndian.Uint32(lenbuf[4:8]))
 
-	if n, err := r.ReadAt(buf, int64(offset)); n != len(buf) { //nolint:gosec // overflow checked above
+	if err := limits.checkEntry(keyLength, valueLength); err != nil {
 		return nil, err
 	}
 
-	var e Entry
-	return &e, e.UnmarshalBinary(buf) //nolint:wsl
+	// Don't allocate the entry if it is cut off.
+	if size, ok := sizeOf(r); ok && keyLength+valueLength > size-min(size, offset+8) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	data, err := readFullAt(r, offset+8, keyLength+valueLength)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entry{Key: data[:keyLength:keyLength], Value: data[keyLength:]}, nil
 }
 
 // Size returns number of bytes in this entry.
@@ -65,6 +94,14 @@ func (e *Entry) Size() uint64 {
 	return uint64(8) + uint64(len(e.Key)) + uint64(len(e.Value))
 }
 
+// clone returns a copy of the entry that doesn't share memory with e.
+func (e *Entry) clone() *Entry {
+	return &Entry{
+		Key:   append([]byte{}, e.Key...),
+		Value: append([]byte{}, e.Value...),
+	}
+}
+
 // WriteTo implements the io.WriterTo interface.
 func (e *Entry) WriteTo(w io.Writer) (n int64, err error) {
 	data, err := e.MarshalBinary()
@@ -104,13 +141,13 @@ func (e *Entry) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
 func (e *Entry) UnmarshalBinary(data []byte) error {
 	if len(data) < 8 {
-		return errors.New("Entry.UnmarshalBinary: invalid length")
+		return fmt.Errorf("Entry.UnmarshalBinary: invalid length: %w", ErrCorrupt)
 	}
 
 	keyLength, valueLength := binary.BigEndian.Uint32(data[:4]), binary.BigEndian.Uint32(data[4:8])
 	expectedLen := uint64(8) + uint64(keyLength) + uint64(valueLength)
 	if uint64(len(data)) != expectedLen {
-		return errors.New("Entry.UnmarshalBinary: invalid length")
+		return fmt.Errorf("Entry.UnmarshalBinary: invalid length: %w", ErrCorrupt)
 	}
 
 	e.Key = make([]byte, keyLength)
@@ -0,0 +1,43 @@
+package sstable
+
+import "errors"
+
+// Errors that callers can tell apart with errors.Is.
+var (
+	// ErrNotRandomAccess is returned by operations that need an
+	// io.ReaderAt when the SSTable reads from a plain io.Reader, and
+	// for readers that are neither.
+	ErrNotRandomAccess = errors.New("sstable: reader is not random access")
+	// ErrCorrupt is returned when the table fails an integrity or
+	// consistency check. Every *CorruptionError is an ErrCorrupt.
+	ErrCorrupt = errors.New("sstable: corrupt table")
+	// ErrOffsetOverflow is returned when an offset doesn't fit in an
+	// int64, which io.ReaderAt and io.Seeker need.
+	ErrOffsetOverflow = errors.New("sstable: offset overflows int64")
+	// ErrCursorInUse is returned by the cursor of a second scan of an
+	// SSTable that reads from a plain io.Reader, which can be scanned
+	// only once.
+	ErrCursorInUse = errors.New("sstable: the reader is already in use by a cursor")
+	// ErrNotSSTable is returned when the footer of a table lacks the
+	// magic bytes. Only the footers of format version 9 and later have
+	// them, so older tables, like those of the default Writer, which
+	// writes format version 2, can't be told from other files.
+	ErrNotSSTable = errors.New("sstable: not an SSTable")
+)
+
+// errCursor is a Cursor that is done with an error.
+type errCursor struct {
+	err error
+}
+
+// Entry returns nil.
+func (c errCursor) Entry() *Entry { return nil }
+
+// Done returns true.
+func (c errCursor) Done() bool { return true }
+
+// Next does nothing.
+func (c errCursor) Next() {}
+
+// Err returns the error.
+func (c errCursor) Err() error { return c.err }
@@ -0,0 +1,120 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+func Example_errors() {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	for _, entry := range exampleFruits {
+		_ = w.Write(entry)
+	}
+
+	w.Close()
+
+	_, err := NewSSTable(42)
+	fmt.Println(errors.Is(err, ErrNotRandomAccess), err)
+
+	// A plain io.Reader can be scanned only once.
+	s, _ := NewSSTable(struct{ io.Reader }{bytes.NewReader(buf.Bytes())})
+	_, _, err = s.Get([]byte("banana"))
+	fmt.Println(errors.Is(err, ErrNotRandomAccess), err)
+
+	s.ScanFrom(nil)
+	c := s.ScanFrom(nil)
+	fmt.Println(c.Done(), errors.Is(c.Err(), ErrCursorInUse), c.Err())
+
+	_, err = ReadEntryAt(bytes.NewReader(buf.Bytes()), math.MaxUint64)
+	fmt.Println(errors.Is(err, ErrOffsetOverflow), err)
+
+	var corruption error = &CorruptionError{Offset: 16, What: "data block"}
+	fmt.Println(errors.Is(corruption, ErrCorrupt))
+	// Output:
+	// true NewSSTable: int is not a reader: sstable: reader is not random access
+	// true SSTable.Get: sstable: reader is not random access
+	// true true SSTable.ScanFrom: sstable: the reader is already in use by a cursor
+	// true ReadEntryAt: sstable: offset overflows int64: 18446744073709551615
+	// true
+}
+
+// writeTable returns the table of the entries that the Writer writes.
+func writeTable(entries []Entry, opts ...WriterOption) ([]byte, error) {
+	f, err := os.CreateTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, opts...)
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	// Close closes the file too.
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(name)
+}
+
+func Example_errorsCorrupt() {
+	// A damaged data block fails with ErrCorrupt in every format
+	// version, with or without checksums. Scans that skip the checksums
+	// fail to decode the block.
+	for version := uint32(versionLegacy); version <= versionLatest; version++ {
+		b, err := writeTable(exampleFruits, WithFormatVersion(version))
+		if err != nil {
+			fmt.Println(version, err)
+			continue
+		}
+
+		s, _ := NewSSTable(bytes.NewReader(b))
+		e, _ := s.blockEntry(nil, 0)
+
+		damaged := bytes.Clone(b)
+		for i := range e.blockLength {
+			damaged[e.blockOffset+uint64(i)] = 0xff
+		}
+
+		s, err = NewSSTable(bytes.NewReader(damaged))
+		if err != nil {
+			fmt.Println(version, err)
+			continue
+		}
+
+		_, _, err = s.Get([]byte("banana"))
+		fmt.Print(version, " ", errors.Is(err, ErrCorrupt))
+
+		for _, err = range Seq(s.ScanRange(nil, nil, ScanOptions{SkipChecksums: true})) {
+			if err != nil {
+				break
+			}
+		}
+
+		fmt.Println("", errors.Is(err, ErrCorrupt))
+	}
+	// Output:
+	// 1 true true
+	// 2 true true
+	// 3 true true
+	// 4 true true
+	// 5 true true
+	// 6 true true
+	// 7 true true
+	// 8 true true
+	// 9 true true
+	// 10 true true
+}
@@ -0,0 +1,98 @@
+package sstable
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// filterMetaBlockName is the name of the meta block that holds the
+// bloom filter over all keys.
+const filterMetaBlockName = "filter.bloom"
+
+// maxFilterBits is the maximum number of bits of a bloom filter, so that
+// the bit positions fit in a uint32.
+const maxFilterBits = 1 << 31
+
+// bloomFilter is a bloom filter. The last byte is the number of probes
+// and the rest is the bit array.
+type bloomFilter []byte
+
+// bloomHash returns the hash of key that the bloom filter probes with.
+func bloomHash(key []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(key) //nolint:errcheck // hash.Hash never returns an error
+
+	return h.Sum64()
+}
+
+// newBloomFilter builds a bloom filter from the hashes of the keys with
+// bitsPerKey bits for each key.
+func newBloomFilter(hashes []uint64, bitsPerKey int) bloomFilter {
+	// 0.69 is approximately ln(2), which minimizes the false positive
+	// rate for the number of bits.
+	k := int(float64(bitsPerKey) * 0.69)
+	k = max(1, min(k, 30))
+
+	bits := min(max(64, len(hashes)*bitsPerKey), maxFilterBits)
+	f := make(bloomFilter, (bits+7)/8+1)
+	bits = (len(f) - 1) * 8
+	f[len(f)-1] = byte(k)
+
+	for _, h := range hashes {
+		h1, h2 := uint32(h), uint32(h>>32) //nolint:gosec // intentionally split into halves
+		for i := 0; i < k; i++ {
+			pos := (h1 + uint32(i)*h2) % uint32(bits) //nolint:gosec // k and bits are small
+			f[pos/8] |= 1 << (pos % 8)
+		}
+	}
+
+	return f
+}
+
+// parseBloomFilter returns the bloom filter in the data of its meta
+// block. It returns ErrCorrupt if the filter has more than maxFilterBits
+// bits.
+func parseBloomFilter(data []byte) (bloomFilter, error) {
+	if len(data) > maxFilterBits/8+1 {
+		return nil, fmt.Errorf("%w: bloom filter of %d bytes is too long", ErrCorrupt, len(data))
+	}
+
+	return bloomFilter(data), nil
+}
+
+// mayContain returns false if the key is definitely not in the filter.
+func (f bloomFilter) mayContain(key []byte) bool {
+	if len(f) < 2 {
+		return true
+	}
+
+	bits := uint32((len(f) - 1) * 8) //nolint:gosec // parseBloomFilter bounds the size
+	k := int(f[len(f)-1])
+	h := bloomHash(key)
+	h1, h2 := uint32(h), uint32(h>>32) //nolint:gosec // intentionally split into halves
+
+	for i := 0; i < k; i++ {
+		pos := (h1 + uint32(i)*h2) % bits //nolint:gosec // k is at most 255
+		if f[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterBuffer collects the key hashes to build a bloom filter.
+type filterBuffer struct {
+	bitsPerKey int
+	hashes     []uint64
+}
+
+// add adds the key to the filter.
+func (b *filterBuffer) add(key []byte) {
+	b.hashes = append(b.hashes, bloomHash(key))
+}
+
+// metaBlock returns the filter as a meta block.
+func (b *filterBuffer) metaBlock() metaBlock {
+	return metaBlock{name: filterMetaBlockName, data: newBloomFilter(b.hashes, b.bitsPerKey)}
+}
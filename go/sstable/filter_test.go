@@ -0,0 +1,128 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// countingReaderAt counts the ReadAt calls to the underlying reader.
+type countingReaderAt struct {
+	*bytes.Reader
+	reads int
+}
+
+func (r *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.reads++
+	return r.Reader.ReadAt(p, off)
+}
+
+func ExampleWithBloomFilter() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithBloomFilter(10))
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+	r := &countingReaderAt{Reader: bytes.NewReader(b)}
+
+	s, err := NewSSTable(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	r.reads = 0
+	value, found, err := s.Get([]byte("banana"))
+	fmt.Println(string(value), found, err, r.reads)
+
+	r.reads = 0
+	value, found, err = s.Get([]byte("blueberry"))
+	fmt.Println(string(value), found, err, r.reads)
+
+	for k, v := range s.All() {
+		fmt.Printf("%s %s\n", k, v)
+	}
+	// Output:
+	// yellow true <nil> 1
+	//  false <nil> 0
+	// apple red
+	// apricot orange
+	// banana yellow
+	// cherry red
+}
+
+//nolint:govet
+func Example_bloomFilter() {
+	var hashes []uint64
+	for i := 0; i < 1000; i++ {
+		hashes = append(hashes, bloomHash([]byte(fmt.Sprint("key", i))))
+	}
+
+	f := newBloomFilter(hashes, 10)
+
+	missing, falsePositives := 0, 0
+	for i := 0; i < 1000; i++ {
+		if !f.mayContain([]byte(fmt.Sprint("key", i))) {
+			missing++
+		}
+
+		if f.mayContain([]byte(fmt.Sprint("other", i))) {
+			falsePositives++
+		}
+	}
+
+	fmt.Println(missing, falsePositives < 30)
+	// Output:
+	// 0 true
+}
+
+// caseInsensitiveComparer orders the keys bytewise ignoring the case of
+// ASCII letters, so that keys of different bytes compare equal.
+type caseInsensitiveComparer struct{}
+
+func (caseInsensitiveComparer) Compare(a, b []byte) int {
+	return bytes.Compare(bytes.ToLower(a), bytes.ToLower(b))
+}
+
+func (caseInsensitiveComparer) Name() string {
+	return "example.case_insensitive"
+}
+
+func ExampleWithBloomFilter_comparer() {
+	for _, filtered := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		w := NewWriter(&buf, WithComparer(caseInsensitiveComparer{}), WithBloomFilter(10))
+		if filtered {
+			// Build the filter like older Writers did, which hashed the
+			// keys regardless of the comparer.
+			w.filter = &filterBuffer{bitsPerKey: 10}
+		}
+
+		_ = w.Write(Entry{Key: []byte("Apple"), Value: []byte("red")})
+		w.Close()
+
+		s, err := NewSSTable(bytes.NewReader(buf.Bytes()), ExpectComparer(caseInsensitiveComparer{}))
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		value, found, err := s.Get([]byte("APPLE"))
+		values, _ := s.GetAll([]byte("apple"))
+		fmt.Printf("%s %v %v %q\n", value, found, err, values)
+	}
+	// Output:
+	// red true <nil> ["red"]
+	// red true <nil> ["red"]
+}
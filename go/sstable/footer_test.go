@@ -0,0 +1,62 @@
+package sstable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+func ExampleWithFooter() {
+	// bytes.Buffer can't seek, so the Writer puts the index offset in
+	// the footer instead of overwriting the header.
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	fmt.Println(w.Close())
+
+	s, err := NewSSTable(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	value, found, err := s.Get([]byte("cherry"))
+	fmt.Println(string(value), found, err)
+
+	// It also streams through a gzip.Writer and reads back in order.
+	var gz bytes.Buffer
+
+	w = NewWriter(gzip.NewWriter(&gz))
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	fmt.Println(w.Close())
+
+	zr, _ := gzip.NewReader(&gz)
+	s, err = NewSSTable(zr)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for k, v := range s.All() {
+		fmt.Printf("%s %s\n", k, v)
+	}
+	// Output:
+	// <nil>
+	// red true <nil>
+	// <nil>
+	// apple red
+	// apricot orange
+	// banana yellow
+	// cherry red
+}
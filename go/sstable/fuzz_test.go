@@ -0,0 +1,291 @@
+package sstable
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// maxAllocs returns the number of bytes that decoding n bytes of input
+// may allocate. It is far below the gigabytes that a corrupt length
+// asks for.
+func maxAllocs(n int) uint64 {
+	return 8<<20 + 512*uint64(n) //nolint:gosec // n is a length
+}
+
+// checkAllocs fails the test if fn allocates more than maxAllocs of n
+// bytes of input.
+func checkAllocs(t *testing.T, n int, fn func()) {
+	t.Helper()
+
+	var before, after runtime.MemStats
+
+	runtime.ReadMemStats(&before)
+	fn()
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > maxAllocs(n) {
+		t.Errorf("decoding %d bytes allocated %d bytes", n, allocated)
+	}
+}
+
+// seedTables returns tables of every format feature to seed the
+// fuzzers that read whole tables.
+func seedTables(tb testing.TB) [][]byte {
+	tb.Helper()
+
+	options := [][]WriterOption{
+		{WithFormatVersion(versionLegacy)},
+		{},
+		{WithBloomFilter(10)},
+		{WithCompression(SnappyCompression), WithChecksums()},
+		{WithFooter(), WithBlockSize(16)},
+		{WithPrefixCompression(2), WithPartitionedIndex(1), WithBlockSize(16)},
+		{WithLargeValues(), WithChecksums()},
+	}
+
+	tables := make([][]byte, 0, len(options))
+
+	for i, opts := range options {
+		name := filepath.Join(tb.TempDir(), strconv.Itoa(i))
+
+		f, err := os.Create(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		w := NewWriter(f, opts...)
+		for _, e := range exampleFruits {
+			if err := w.Write(e); err != nil {
+				tb.Fatal(err)
+			}
+		}
+
+		if w.version >= versionLargeValues {
+			value := strings.Repeat("green", 10)
+			if err := w.WriteValueFrom([]byte("kiwi"), strings.NewReader(value), int64(len(value))); err != nil {
+				tb.Fatal(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			tb.Fatal(err)
+		}
+
+		table, err := os.ReadFile(name)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables
+}
+
+// fuzzLimits are the limits that the fuzzers read with.
+var fuzzLimits = ReadLimits{MaxKeySize: 1 << 10, MaxValueSize: 1 << 16, MaxBlockSize: 1 << 16}
+
+// readTable reads everything from the table in data the ways that
+// SSTables do.
+func readTable(data []byte) {
+	limits := fuzzLimits
+
+	_, _ = Verify(bytes.NewReader(data), VerifyOptions{Limits: limits})
+	_, _ = Recover(bytes.NewReader(data), NewWriter(io.Discard), RecoverOptions{Resync: true, Limits: limits})
+
+	for _, r := range []interface{}{bytes.NewReader(data), struct{ io.Reader }{bytes.NewReader(data)}} {
+		s, err := NewSSTable(r, WithReadLimits(limits))
+		if err != nil {
+			continue
+		}
+
+		c := s.ScanFrom(nil)
+		for ; !c.Done(); c.Next() {
+			_ = c.Entry()
+		}
+
+		for _, key := range []string{"", "banana", "kiwi", "zzz"} {
+			_, _, _ = s.Get([]byte(key))
+			_, _ = s.GetAll([]byte(key))
+
+			if v, _, _, err := s.OpenValue([]byte(key)); err == nil && v != nil {
+				_, _ = io.Copy(io.Discard, v)
+			}
+		}
+
+		if it, err := s.NewIterator(); err == nil {
+			for it.SeekToLast(); !it.Done(); it.Prev() {
+				_ = it.Entry()
+			}
+		}
+	}
+}
+
+func FuzzNewSSTable(f *testing.F) {
+	for _, table := range seedTables(f) {
+		f.Add(table)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() { readTable(data) })
+	})
+}
+
+func FuzzReadEntry(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 1, 0, 0, 0, 1, 'a', 'b'})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 'a'})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			_, _ = ReadEntry(bytes.NewReader(data))
+			_, _ = ReadEntryAt(bytes.NewReader(data), 0)
+			_, _, _ = readIndexEntry(bytes.NewReader(data))
+			_, _ = readIndexEntryAt(bytes.NewReader(data), 0)
+
+			var meta metaIndex
+			_, _ = meta.ReadFrom(bytes.NewReader(data))
+		})
+	})
+}
+
+func FuzzEntryUnmarshalBinary(f *testing.F) {
+	for _, e := range exampleFruits {
+		data, _ := e.MarshalBinary()
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			var e Entry
+			if e.UnmarshalBinary(data) != nil {
+				return
+			}
+
+			if got, err := e.MarshalBinary(); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("round trip of %x gives %x, %v", data, got, err)
+			}
+		})
+	})
+}
+
+func FuzzHeaderUnmarshalBinary(f *testing.F) {
+	for _, table := range seedTables(f) {
+		f.Add(table[:headerSize])
+		f.Add(table[len(table)-min(len(table), footerSize+len(magic)):])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			var h header
+			if h.UnmarshalBinary(data) == nil {
+				_ = h.checkVersion()
+				_ = h.checkIndexOffset()
+			}
+
+			for _, version := range []uint32{versionFooter, versionMagic} {
+				h := header{version: version}
+				_ = h.unmarshalFooter(data, 0)
+			}
+		})
+	})
+}
+
+func FuzzIndexEntryUnmarshalBinary(f *testing.F) {
+	data, _ := (&indexEntry{blockOffset: 16, blockLength: 42, keyBytes: []byte("apple")}).MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			var e indexEntry
+			if e.UnmarshalBinary(data) != nil {
+				return
+			}
+
+			if got, err := e.MarshalBinary(); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("round trip of %x gives %x, %v", data, got, err)
+			}
+		})
+	})
+}
+
+func FuzzPartitionsUnmarshalBinary(f *testing.F) {
+	data, _ := (&partitions{blocksPerPartition: 128, numBlocks: 1000}).MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			var p partitions
+			if p.UnmarshalBinary(data) != nil {
+				return
+			}
+
+			if got, err := p.MarshalBinary(); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("round trip of %x gives %x, %v", data, got, err)
+			}
+		})
+	})
+}
+
+func FuzzPropertiesUnmarshalBinary(f *testing.F) {
+	props := Properties{NumEntries: 3, SmallestKey: []byte("apple"), Comparer: "sstable.bytewise", User: map[string]string{"a": "b"}}
+	data, _ := props.MarshalBinary()
+	f.Add(data)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			var p Properties
+			_ = p.UnmarshalBinary(data)
+		})
+	})
+}
+
+func FuzzBlock(f *testing.F) {
+	var b prefixBlockBuilder
+
+	b.restartInterval = 2
+	for _, e := range exampleFruits {
+		b.add(e.Key, e.Value)
+	}
+
+	f.Add(append([]byte{prefixEncoding}, b.finish()...))
+
+	raw, _ := marshalBlobBlock([]byte("kiwi"), &blobRef{offset: 16, length: 50})
+	f.Add(raw)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkAllocs(t, len(data), func() {
+			for _, version := range []uint32{versionBase, versionPrefixKeys, versionLargeValues} {
+				format := blockFormatOf(version)
+				_, _ = format.entries(data, nil, fuzzLimits)
+				_, _, _ = format.find(data, []byte("banana"), BytewiseComparer, fuzzLimits)
+				_, _ = format.decodeBlock(data, 0, true, fuzzLimits)
+			}
+
+			_, _ = snappyDecode(data)
+		})
+	})
+}
+
+func FuzzSnappy(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte("hello"))
+	f.Add([]byte(strings.Repeat("abcdefgh", 100) + "the end"))
+	f.Add(bytes.Repeat([]byte{0}, 1<<17))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decoded, err := snappyDecode(snappyEncode(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("round trip of %d bytes gave %d different bytes", len(data), len(decoded))
+		}
+	})
+}
@@ -0,0 +1,22 @@
+// Command generate rebuilds the golden corpus in the directory of its
+// argument. It is run by go generate in the golden package.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jaeyeom/sstable/go/sstable/golden"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: generate dir")
+		os.Exit(2)
+	}
+
+	if err := golden.Generate(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
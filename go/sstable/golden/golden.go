@@ -0,0 +1,163 @@
+// Package golden holds the corpus of SSTable files that pins the file
+// format. The files in testdata must only change when the format
+// changes on purpose; rebuild them with
+//
+//	go generate ./go/sstable/golden
+package golden
+
+//go:generate go run ./generate testdata
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jaeyeom/sstable/go/sstable"
+)
+
+// Case is a table of the corpus.
+type Case struct {
+	// Name is the file name of the table without the extension.
+	Name string
+	// Entries are the entries that the table holds.
+	Entries []sstable.Entry
+	// Options configure the Writer of the table.
+	Options []sstable.WriterOption
+	// Streamed lists the keys whose values are written with
+	// WriteValueFrom, so that they are stored out of line.
+	Streamed []string
+}
+
+// blockSize is the default block size of the Writer.
+const blockSize = 64 * 1024
+
+// creationTime is the creation time of the tables that record one.
+var creationTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Cases returns the tables of the corpus.
+func Cases() []Case {
+	fruits := []sstable.Entry{
+		{Key: []byte("apple"), Value: []byte("red")},
+		{Key: []byte("apricot"), Value: []byte("orange")},
+		{Key: []byte("banana"), Value: []byte("yellow")},
+		{Key: []byte("cherry"), Value: []byte("red")},
+	}
+
+	return []Case{
+		{Name: "empty"},
+		{Name: "single_block", Entries: fruits},
+		{
+			// The first entry fills a block exactly and the next two
+			// fill the second block exactly. The last one still joins
+			// the second block, since only the length of the value
+			// decides whether an entry fits.
+			Name: "block_boundary",
+			Entries: []sstable.Entry{
+				{Key: []byte("a"), Value: bytes.Repeat([]byte("a"), blockSize-8-1)},
+				{Key: []byte("b"), Value: bytes.Repeat([]byte("b"), blockSize-8-1-8-1-8)},
+				{Key: []byte("c"), Value: bytes.Repeat([]byte("c"), 8)},
+				{Key: []byte("d")},
+			},
+		},
+		{
+			Name: "large_value",
+			Entries: []sstable.Entry{
+				{Key: []byte("large"), Value: bytes.Repeat([]byte("0123456789abcdef"), 3*blockSize/16)},
+				{Key: []byte("small"), Value: []byte("value")},
+			},
+		},
+		{
+			Name: "binary_keys",
+			Entries: []sstable.Entry{
+				{Key: []byte{}, Value: []byte("empty key")},
+				{Key: []byte{0x00}, Value: []byte{0x00}},
+				{Key: []byte{0x00, 0x00}},
+				{Key: []byte{0x7f, 0x80, 0xff}, Value: []byte{0xff, 0xfe}},
+				{Key: []byte{0xff, 0xff, 0xff, 0xff}, Value: []byte("last")},
+			},
+		},
+		{Name: "legacy_version", Entries: fruits, Options: []sstable.WriterOption{sstable.WithFormatVersion(1)}},
+		{
+			Name:    "magic_version",
+			Entries: fruits,
+			Options: []sstable.WriterOption{
+				sstable.WithFormatVersion(9),
+				sstable.WithCompression(sstable.SnappyCompression),
+				sstable.WithPrefixCompression(2),
+				sstable.WithPartitionedIndex(1),
+				sstable.WithBloomFilter(10),
+				sstable.WithBlockSize(32),
+				sstable.WithCreationTime(creationTime),
+			},
+		},
+		{
+			Name: "latest_version",
+			Entries: []sstable.Entry{
+				fruits[0],
+				fruits[1],
+				{Key: []byte("banana"), Value: bytes.Repeat([]byte("yellow"), 100)},
+				fruits[3],
+			},
+			Options: []sstable.WriterOption{
+				sstable.WithFormatVersion(10),
+				sstable.WithLargeValues(),
+				sstable.WithChecksums(),
+				sstable.WithCompression(sstable.SnappyCompression),
+				sstable.WithPrefixCompression(2),
+				sstable.WithPartitionedIndex(1),
+				sstable.WithBloomFilter(10),
+				sstable.WithBlockSize(32),
+				sstable.WithCreationTime(creationTime),
+			},
+			Streamed: []string{"banana"},
+		},
+	}
+}
+
+// Path returns the path of the table of the case in dir.
+func (c Case) Path(dir string) string {
+	return filepath.Join(dir, c.Name+".sst")
+}
+
+// Write writes the table of the case to path.
+func (c Case) Write(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := sstable.NewWriter(f, c.Options...)
+	for _, e := range c.Entries {
+		if slices.Contains(c.Streamed, string(e.Key)) {
+			err = w.WriteValueFrom(e.Key, bytes.NewReader(e.Value), int64(len(e.Value)))
+		} else {
+			err = w.Write(e)
+		}
+
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", c.Name, err)
+		}
+	}
+
+	// Close closes the file too.
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", c.Name, err)
+	}
+
+	return nil
+}
+
+// Generate writes the tables of all the cases to dir.
+func Generate(dir string) error {
+	for _, c := range Cases() {
+		if err := c.Write(c.Path(dir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package golden
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jaeyeom/sstable/go/sstable"
+)
+
+// matches returns true if the table holds exactly the entries.
+func matches(s *sstable.SSTable, entries []sstable.Entry) (bool, error) {
+	i := 0
+
+	for e, err := range s.Entries(nil, nil) {
+		if err != nil {
+			return false, err
+		}
+
+		if i >= len(entries) || !bytes.Equal(e.Key, entries[i].Key) || !bytes.Equal(e.Value, entries[i].Value) {
+			return false, nil
+		}
+
+		i++
+	}
+
+	return i == len(entries), nil
+}
+
+func ExampleCases() {
+	dir, _ := os.MkdirTemp("", "")
+	defer os.RemoveAll(dir)
+
+	for _, c := range Cases() {
+		want, err := os.ReadFile(c.Path("testdata"))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		// The Writer writes the golden table byte for byte.
+		if err := c.Write(c.Path(dir)); err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		got, _ := os.ReadFile(filepath.Join(dir, c.Name+".sst"))
+
+		// The SSTable reads the entries from the golden table.
+		s, err := sstable.NewSSTable(bytes.NewReader(want))
+		if err != nil {
+			fmt.Println(c.Name, err)
+			continue
+		}
+
+		ok, err := matches(s, c.Entries)
+		fmt.Println(c.Name, bytes.Equal(got, want), ok, err)
+	}
+	// Output:
+	// empty true true <nil>
+	// single_block true true <nil>
+	// block_boundary true true <nil>
+	// large_value true true <nil>
+	// binary_keys true true <nil>
+	// legacy_version true true <nil>
+	// magic_version true true <nil>
+	// latest_version true true <nil>
+}
@@ -11,6 +11,60 @@ import (
 // headerSize is the number of bytes of the header.
 const headerSize = 16
 
+// Format versions. Each version can hold everything that the previous
+// versions can.
+const (
+	// versionLegacy is the first format of mariusaeriksen/sstable. Its
+	// layout is the same as versionBase.
+	versionLegacy = 1
+	// versionBase is the format of mariusaeriksen/sstable. The index
+	// runs from the index offset to the end of the file.
+	versionBase = 2
+	// versionMetaBlocks ends the index after numBlocks entries and
+	// follows it with the meta index and the meta blocks.
+	versionMetaBlocks = 3
+	// versionCompression frames every data block with its length and
+	// the ID of the codec that compressed it.
+	versionCompression = 4
+	// versionChecksums ends every data block, the index and the meta
+	// blocks with a CRC32C checksum.
+	versionChecksums = 5
+	// versionFooter writes the header once without the index offset
+	// and the number of blocks, ends the data blocks with a block
+	// header of endOfBlocks and puts the complete header in a footer at
+	// the end of the file, so that the writer doesn't need to seek.
+	versionFooter = 6
+	// versionPrefixKeys starts every raw data block with its encoding,
+	// which can store the keys as deltas from the previous keys with
+	// periodic restart points.
+	versionPrefixKeys = 7
+	// versionPartitionedIndex may split the index into partitions that
+	// follow the data blocks. The index then points to the partitions,
+	// the number of blocks counts the partitions and a meta block
+	// describes them.
+	versionPartitionedIndex = 8
+	// versionMagic ends the footer with magic bytes, so that readers
+	// can tell an SSTable from any other file.
+	versionMagic = 9
+	// versionLargeValues may store large values out of line in blob
+	// frames between the data blocks.
+	versionLargeValues = 10
+	// versionLatest is the newest format version.
+	versionLatest = versionLargeValues
+)
+
+// ErrUnsupportedVersion is returned when a table has a format version
+// that this package can't read.
+var ErrUnsupportedVersion = errors.New("sstable: unsupported format version")
+
+// footerSize is the number of bytes of the footer: the header and its
+// checksum. The footers of versionMagic and later are followed by the
+// magic bytes.
+const footerSize = headerSize + checksumSize
+
+// magic ends the files of versionMagic and later.
+var magic = [...]byte{0x89, 'S', 'S', 'T', '\r', '\n', 0x1a, '\n'}
+
 // header implements binary IO and marshal functions.
 type header struct {
 	version     uint32
@@ -30,6 +84,96 @@ func (h *header) read(r io.Reader) error {
 		return fmt.Errorf("failed to unmarshal header: %w", err)
 	}
 
+	return h.checkVersion()
+}
+
+// checkVersion returns ErrUnsupportedVersion if the version isn't
+// known.
+func (h *header) checkVersion() error {
+	if h.version < versionLegacy || h.version > versionLatest {
+		return fmt.Errorf("%w %d", ErrUnsupportedVersion, h.version)
+	}
+
+	return nil
+}
+
+// checkIndexOffset returns an error if the index offset points into
+// the header, which only happens with files that aren't SSTables. The
+// Writer of format version 2 leaves the index offset of an empty table
+// at 0, so that means the empty index after the header.
+func (h *header) checkIndexOffset() error {
+	if h.indexOffset == 0 && h.numBlocks == 0 && h.version <= versionBase {
+		h.indexOffset = headerSize
+	}
+
+	if h.indexOffset < headerSize {
+		return fmt.Errorf("%w: index offset %d is inside the header", ErrCorrupt, h.indexOffset)
+	}
+
+	return nil
+}
+
+// footerSize returns the number of bytes at the end of the file that
+// marshalFooter returns.
+func (h *header) footerSize() int {
+	if h.version >= versionMagic {
+		return footerSize + len(magic)
+	}
+
+	return footerSize
+}
+
+// marshalFooter returns the footer of the header followed by the magic
+// bytes if the version has them.
+func (h *header) marshalFooter() ([]byte, error) {
+	data, err := h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	data = binary.BigEndian.AppendUint32(data, checksum(data))
+	if h.version >= versionMagic {
+		data = append(data, magic[:]...)
+	}
+
+	return data, nil
+}
+
+// unmarshalFooter parses the footer that marshalFooter returns into
+// the header. The version in the footer must match the version of the
+// header.
+func (h *header) unmarshalFooter(data []byte, offset uint64) error {
+	if len(data) != h.footerSize() {
+		return fmt.Errorf("header.unmarshalFooter: invalid length: %w", ErrCorrupt)
+	}
+
+	if h.version >= versionMagic {
+		if !bytes.Equal(data[footerSize:], magic[:]) {
+			return fmt.Errorf("header.unmarshalFooter: no magic bytes: %w", ErrNotSSTable)
+		}
+
+		data = data[:footerSize]
+	}
+
+	if binary.BigEndian.Uint32(data[headerSize:]) != checksum(data[:headerSize]) {
+		return &CorruptionError{Offset: offset, What: "footer"}
+	}
+
+	var footer header
+	if err := footer.UnmarshalBinary(data[:headerSize]); err != nil {
+		return err
+	}
+
+	if footer.version != h.version {
+		return fmt.Errorf("%w: footer version %d doesn't match the header version %d", ErrCorrupt, footer.version, h.version)
+	}
+
+	if footer.indexOffset > offset {
+		return fmt.Errorf("%w: index offset %d is beyond the footer at %d", ErrCorrupt, footer.indexOffset, offset)
+	}
+
+	*h = footer
+
 	return nil
 }
 
@@ -57,7 +201,7 @@ func (h *header) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
 func (h *header) UnmarshalBinary(data []byte) error {
 	if len(data) != headerSize {
-		return errors.New("header.UnmarshalBinary: invalid length")
+		return fmt.Errorf("header.UnmarshalBinary: invalid length: %w", ErrCorrupt)
 	}
 
 	h.version = binary.BigEndian.Uint32(data[:4])
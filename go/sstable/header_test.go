@@ -1,8 +1,12 @@
 package sstable
 
 import (
+	"bytes"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
 )
 
 //nolint:govet
@@ -35,3 +39,86 @@ func Example_headerUnmarshalBinary() {
 	// Output:
 	// {1 2 3}
 }
+
+func ExampleErrUnsupportedVersion() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithFormatVersion(versionLatest))
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	binary.BigEndian.PutUint32(b, 42)
+	_, err := NewSSTable(bytes.NewReader(b))
+	fmt.Println(errors.Is(err, ErrUnsupportedVersion), err)
+
+	fmt.Println(NewWriter(f, WithFormatVersion(42)).Write(exampleFruits[0]))
+	// Output:
+	// true sstable: unsupported format version 42
+	// NewWriter: unknown format version 42
+}
+
+func ExampleErrNotSSTable() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithFormatVersion(versionMagic))
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+	fmt.Printf("%q\n", b[len(b)-len(magic):])
+
+	// Files that end otherwise aren't SSTables.
+	_, err := NewSSTable(bytes.NewReader(append(b, '\n')))
+	fmt.Println(errors.Is(err, ErrNotSSTable), errors.Is(err, ErrCorrupt), err)
+	// Output:
+	// "\x89SST\r\n\x1a\n"
+	// true false header.unmarshalFooter: no magic bytes: sstable: not an SSTable
+}
+
+func ExampleWithFormatVersion_legacy() {
+	for _, version := range []uint32{versionLegacy, versionBase} {
+		f, _ := os.CreateTemp("", "")
+		defer os.Remove(f.Name())
+
+		w := NewWriter(f, WithFormatVersion(version))
+		for _, entry := range exampleFruits {
+			if err := w.Write(entry); err != nil {
+				fmt.Println(err)
+			}
+		}
+
+		w.Close()
+
+		b, _ := os.ReadFile(f.Name())
+
+		s, err := NewSSTable(bytes.NewReader(b))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		value, found, err := s.Get([]byte("banana"))
+		fmt.Println(b[3], string(value), found, err)
+	}
+	// Output:
+	// 1 yellow true <nil>
+	// 2 yellow true <nil>
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sort"
@@ -27,26 +28,26 @@ func readIndexEntry(r io.Reader) (*indexEntry, int, error) {
 		return nil, n, err
 	}
 
-	length := binary.BigEndian.Uint32(lenbuf)
-	buf := make([]byte, length+16)
-	copy(buf[:4], lenbuf)
+	rest, err := readFull(r, 12+uint64(binary.BigEndian.Uint32(lenbuf)))
+	n += len(rest)
 
-	nn, err := io.ReadFull(r, buf[4:])
-	n += nn
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
 
 	if err != nil {
 		return nil, n, err
 	}
 
 	var e indexEntry
-	return &e, n, e.UnmarshalBinary(buf) //nolint:wsl
+	return &e, n, e.UnmarshalBinary(append(lenbuf, rest...)) //nolint:wsl
 }
 
 // readIndexEntryAt reads indexEntry at offset from r and returns
 // indexEntry and error.
 func readIndexEntryAt(r io.ReaderAt, offset uint64) (*indexEntry, error) {
-	if offset > math.MaxInt64 {
-		panic("unimplemented")
+	if offset > math.MaxInt64-4 {
+		return nil, fmt.Errorf("readIndexEntryAt: %w: %d", ErrOffsetOverflow, offset)
 	}
 
 	lenbuf := make([]byte, 4)
@@ -54,15 +55,17 @@ func readIndexEntryAt(r io.ReaderAt, offset uint64) (*indexEntry, error) {
 		return nil, err
 	}
 
-	length := binary.BigEndian.Uint32(lenbuf)
-	buf := make([]byte, length+16)
+	rest, err := readFullAt(r, offset+4, 12+uint64(binary.BigEndian.Uint32(lenbuf)))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
 
-	if n, err := r.ReadAt(buf, int64(offset)); n != len(buf) { //nolint:gosec // overflow checked above
 		return nil, err
 	}
 
 	var e indexEntry
-	return &e, e.UnmarshalBinary(buf) //nolint:wsl
+	return &e, e.UnmarshalBinary(append(lenbuf, rest...)) //nolint:wsl
 }
 
 // size returns number of bytes of the indexEntry.
@@ -110,7 +113,7 @@ func (e *indexEntry) MarshalBinary() ([]byte, error) {
 // UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
 func (e *indexEntry) UnmarshalBinary(data []byte) error {
 	if len(data) < 16 {
-		return errors.New("indexEntry.UnmarshalBinary: invalid length")
+		return fmt.Errorf("indexEntry.UnmarshalBinary: invalid length: %w", ErrCorrupt)
 	}
 
 	length := binary.BigEndian.Uint32(data[:4])
@@ -118,7 +121,7 @@ func (e *indexEntry) UnmarshalBinary(data []byte) error {
 	blockLength := binary.BigEndian.Uint32(data[12:16])
 
 	if uint64(length) != uint64(len(data[16:])) {
-		return errors.New("indexEntry.UnmarshalBinary: invalid length")
+		return fmt.Errorf("indexEntry.UnmarshalBinary: invalid length: %w", ErrCorrupt)
 	}
 
 	e.blockOffset = blockOffset
@@ -132,11 +135,43 @@ func (e *indexEntry) UnmarshalBinary(data []byte) error {
 // index stores index entries and implements a find function.
 type index []indexEntry
 
+// check returns ErrCorrupt unless the blocks of the index entries are
+// in order between the header and end, and the keys are within the
+// limits.
+func (i index) check(end uint64, limits ReadLimits) error {
+	offset := uint64(headerSize)
+
+	for _, e := range i {
+		if e.blockOffset < offset || e.blockOffset > end || uint64(e.blockLength) > end-e.blockOffset {
+			return fmt.Errorf("%w: block at offset %d is out of place", ErrCorrupt, e.blockOffset)
+		}
+
+		if err := limits.checkEntry(uint64(len(e.keyBytes)), 0); err != nil {
+			return err
+		}
+
+		offset = e.blockOffset + uint64(e.blockLength)
+	}
+
+	return nil
+}
+
 // entryIndexOf returns the index of index entry that might contain
-// the key. It returns -1 if there is no index entry.
-func (i index) entryIndexOf(key []byte) int {
+// the key in the order of cmp. It returns -1 if there is no index
+// entry.
+func (i index) entryIndexOf(key []byte, cmp Comparer) int {
 	return sort.Search(len(i), func(idx int) bool {
-		return bytes.Compare(i[idx].keyBytes, key) > 0
+		return cmp.Compare(i[idx].keyBytes, key) > 0
+	}) - 1
+}
+
+// entryIndexBefore returns the index of the last index entry whose key
+// is less than the key in the order of cmp, which is the first block
+// that might contain the key when equal keys span blocks. It returns -1
+// if there is no such index entry.
+func (i index) entryIndexBefore(key []byte, cmp Comparer) int {
+	return sort.Search(len(i), func(idx int) bool {
+		return cmp.Compare(i[idx].keyBytes, key) >= 0
 	}) - 1
 }
 
@@ -160,6 +195,24 @@ func (i *index) ReadFrom(r io.Reader) (n int64, err error) {
 	panic("unreachable")
 }
 
+// readN reads n index entries from r.
+func (i *index) readN(r io.Reader, n uint32) error {
+	for range n {
+		e, _, err := readIndexEntry(r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return err
+		}
+
+		*i = append(*i, *e)
+	}
+
+	return nil
+}
+
 // ReadAt reads index from r at offset.
 func (i *index) ReadAt(r io.ReaderAt, offset uint64) error {
 	var err error
@@ -204,10 +257,20 @@ type indexBuffer struct {
 	index          index
 }
 
-// Write writes an entry in the buffer to build the index.
-func (w *indexBuffer) Write(key []byte, valueSize uint32) {
+// Write writes an entry in the buffer to build the index. The entry
+// starts a new block if the block and the value exceed the block size,
+// as in the tables of format version 2. It also starts a new block if
+// the length of the block would overflow the index entry.
+func (w *indexBuffer) Write(key []byte, valueSize uint32) error {
+	length := 8 + uint64(len(key)) + uint64(valueSize)
+	if length > math.MaxUint32 {
+		return fmt.Errorf("indexBuffer.Write: entry of %d bytes is too large for a block", length)
+	}
+
 	size := len(w.index)
-	if size == 0 || int64(w.index[size-1].blockLength)+int64(valueSize) > int64(w.maxBlockLength) {
+	if size == 0 ||
+		uint64(w.index[size-1].blockLength)+uint64(valueSize) > uint64(w.maxBlockLength) ||
+		uint64(w.index[size-1].blockLength)+length > math.MaxUint32 {
 		w.index = append(w.index, indexEntry{
 			blockOffset: w.offset,
 			keyBytes:    key,
@@ -215,6 +278,8 @@ func (w *indexBuffer) Write(key []byte, valueSize uint32) {
 		size++
 	}
 
-	w.offset += uint64(8) + uint64(len(key)) + uint64(valueSize)
-	w.index[size-1].blockLength += uint32(8) + uint32(len(key)) + valueSize //nolint:gosec // key length is bounded by maxBlockLength
+	w.offset += length
+	w.index[size-1].blockLength += uint32(length) //nolint:gosec // the block length stays within math.MaxUint32 above
+
+	return nil
 }
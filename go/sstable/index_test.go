@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math"
 )
 
 //nolint:govet
@@ -11,25 +12,44 @@ func Example_indexBufferWrite() {
 	w := &indexBuffer{
 		maxBlockLength: 64 * 1024,
 	}
-	w.Write([]byte{1, 2, 3}, 30000)
-	w.Write([]byte{1, 2, 3, 4}, 30000)
-	w.Write([]byte{2, 3, 4}, 30000)
+	_ = w.Write([]byte{1, 2, 3}, 30000)
+	_ = w.Write([]byte{1, 2, 3, 4}, 30000)
+	_ = w.Write([]byte{2, 3, 4}, 30000)
 	fmt.Println(w.index)
 	// Output:
 	// [{0 60023 [1 2 3]} {60023 30011 [2 3 4]}]
 }
 
+//nolint:govet
+func Example_indexBufferWrite_overflow() {
+	// The largest block size would let the block length wrap around.
+	w := &indexBuffer{
+		maxBlockLength: math.MaxUint32,
+	}
+	fmt.Println(w.Write([]byte{1}, 3<<30))
+	fmt.Println(w.Write([]byte{2}, 1<<30))
+	fmt.Println(w.Write([]byte{3}, 1<<30))
+	fmt.Println(w.Write([]byte{4}, math.MaxUint32))
+	fmt.Println(w.index)
+	// Output:
+	// <nil>
+	// <nil>
+	// <nil>
+	// indexBuffer.Write: entry of 4294967304 bytes is too large for a block
+	// [{0 3221225481 [1]} {3221225481 2147483666 [2]}]
+}
+
 //nolint:govet
 func Example_indexEntryIndexOf() {
 	i := &index{
 		{0, 60023, []byte{1, 2, 3}},
 		{60023, 30011, []byte{2, 3, 4}},
 	}
-	fmt.Println(i.entryIndexOf([]byte{1, 2}))
-	fmt.Println(i.entryIndexOf([]byte{1, 2, 3}))
-	fmt.Println(i.entryIndexOf([]byte{1, 2, 3, 4}))
-	fmt.Println(i.entryIndexOf([]byte{2, 3, 4}))
-	fmt.Println(i.entryIndexOf([]byte{2, 3, 5}))
+	fmt.Println(i.entryIndexOf([]byte{1, 2}, BytewiseComparer))
+	fmt.Println(i.entryIndexOf([]byte{1, 2, 3}, BytewiseComparer))
+	fmt.Println(i.entryIndexOf([]byte{1, 2, 3, 4}, BytewiseComparer))
+	fmt.Println(i.entryIndexOf([]byte{2, 3, 4}, BytewiseComparer))
+	fmt.Println(i.entryIndexOf([]byte{2, 3, 5}, BytewiseComparer))
 	// Output:
 	// -1
 	// 0
@@ -0,0 +1,191 @@
+package sstable
+
+import (
+	"iter"
+)
+
+// All returns an iterator over all keys and values of the SSTable.
+// The iteration stops at the first read error; use Entries or Pairs to
+// observe it.
+func (s *SSTable) All() iter.Seq2[[]byte, []byte] {
+	return s.Range(nil, nil)
+}
+
+// Range returns an iterator over the keys and values from the key from
+// up to, but not including, the key to. A nil from starts at the
+// beginning and a nil to ends at the end of the SSTable. The iteration
+// stops at the first read error; use Entries or Pairs to observe it.
+func (s *SSTable) Range(from, to []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for e, err := range s.Entries(from, to) {
+			if err != nil || !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Prefix returns an iterator over the keys and values whose keys start
+// with p. The iteration stops at the first read error; use
+// PrefixEntries or Pairs to observe it.
+func (s *SSTable) Prefix(p []byte) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for e, err := range s.PrefixEntries(p) {
+			if err != nil || !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// PrefixEntries returns an iterator over the entries whose keys start
+// with p. If reading fails, the last pair yielded has a nil entry and
+// the error.
+func (s *SSTable) PrefixEntries(p []byte) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		Seq(s.ScanRange(nil, nil, ScanOptions{Prefix: p}))(yield)
+	}
+}
+
+// Entries returns an iterator over the entries from the key from up
+// to, but not including, the key to. A nil from starts at the
+// beginning and a nil to ends at the end of the SSTable. If reading
+// fails, the last pair yielded has a nil entry and the error.
+func (s *SSTable) Entries(from, to []byte) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		Seq(s.ScanRange(from, to, ScanOptions{}))(yield)
+	}
+}
+
+// Seq returns an iterator over the entries of c. If c fails, the last
+// pair yielded has a nil entry and the error of c. The cursor is left
+// where the loop body stopped.
+func Seq(c Cursor) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		for ; !c.Done(); c.Next() {
+			if !yield(c.Entry(), nil) {
+				return
+			}
+		}
+
+		if err := c.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Pairs returns an iterator over the keys and values of the entries of
+// seq, like All, Range and Prefix do, and a function that returns the
+// error that ended the iteration, if any. The error is only known once
+// the loop is over.
+func Pairs(seq iter.Seq2[*Entry, error]) (iter.Seq2[[]byte, []byte], func() error) {
+	var err error
+
+	pairs := func(yield func([]byte, []byte) bool) {
+		err = nil
+
+		for e, eerr := range seq {
+			if eerr != nil {
+				err = eerr
+				return
+			}
+
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+
+	return pairs, func() error { return err }
+}
+
+// KeyValues returns an iterator over the keys and values of c. The
+// iteration stops at the first error; check c.Err afterwards.
+func KeyValues(c Cursor) iter.Seq2[[]byte, []byte] {
+	return func(yield func([]byte, []byte) bool) {
+		for ; !c.Done(); c.Next() {
+			e := c.Entry()
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+// SeqCursor is a Cursor that pulls entries from an iterator. Call
+// Close if the cursor is abandoned before it is done.
+type SeqCursor struct {
+	next  func() (*Entry, error, bool)
+	stop  func()
+	entry *Entry
+	err   error
+	done  bool
+}
+
+// NewSeqCursor returns a cursor over the entries of seq. A non-nil
+// error from seq ends the cursor and is reported by Err.
+func NewSeqCursor(seq iter.Seq2[*Entry, error]) *SeqCursor {
+	next, stop := iter.Pull2(seq)
+
+	c := &SeqCursor{next: next, stop: stop}
+	c.Next()
+
+	return c
+}
+
+// Entry returns the current entry. It returns nil if the cursor is
+// done.
+func (c *SeqCursor) Entry() *Entry {
+	return c.entry
+}
+
+// Done returns true when there is no more entry or the iterator
+// failed.
+func (c *SeqCursor) Done() bool {
+	return c.done
+}
+
+// Next moves the cursor to the next entry.
+func (c *SeqCursor) Next() {
+	if c.done {
+		return
+	}
+
+	e, err, ok := c.next()
+	if !ok || err != nil {
+		c.err = err
+		c.Close()
+
+		return
+	}
+
+	c.entry = e
+}
+
+// Err returns the error that ended the cursor, if any.
+func (c *SeqCursor) Err() error {
+	return c.err
+}
+
+// Close stops the underlying iterator. The cursor is done afterwards.
+func (c *SeqCursor) Close() {
+	c.done = true
+	c.entry = nil
+	c.stop()
+}
+
+// prefixSuccessor returns the smallest key that is greater than every
+// key starting with p. It returns nil if there is no such key.
+func prefixSuccessor(p []byte) []byte {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i] != 0xff {
+			s := make([]byte, i+1)
+			copy(s, p)
+			s[i]++
+
+			return s
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// newExampleTable writes entries to a temporary file and opens it as
+// an SSTable. The returned function removes the file.
+func newExampleTable(entries []Entry) (*SSTable, func()) {
+	f, _ := os.CreateTemp("", "")
+	name := f.Name()
+
+	w := NewWriter(f)
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	f2, _ := os.Open(name)
+	s, err := NewSSTable(f2)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	return s, func() {
+		f2.Close()
+		os.Remove(name)
+	}
+}
+
+var exampleFruits = []Entry{
+	{Key: []byte("apple"), Value: []byte("red")},
+	{Key: []byte("apricot"), Value: []byte("orange")},
+	{Key: []byte("banana"), Value: []byte("yellow")},
+	{Key: []byte("cherry"), Value: []byte("red")},
+}
+
+func ExampleSSTable_All() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	for k, v := range s.All() {
+		fmt.Printf("%s %s\n", k, v)
+	}
+	// Output:
+	// apple red
+	// apricot orange
+	// banana yellow
+	// cherry red
+}
+
+func ExampleSSTable_Range() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	for k := range s.Range([]byte("apricot"), []byte("cherry")) {
+		fmt.Printf("%s\n", k)
+	}
+
+	fmt.Println("---")
+
+	for k := range s.Range(nil, nil) {
+		if bytes.Equal(k, []byte("banana")) {
+			break
+		}
+
+		fmt.Printf("%s\n", k)
+	}
+	// Output:
+	// apricot
+	// banana
+	// ---
+	// apple
+	// apricot
+}
+
+func ExampleSSTable_Prefix() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	for k, v := range s.Prefix([]byte("ap")) {
+		fmt.Printf("%s %s\n", k, v)
+	}
+	// Output:
+	// apple red
+	// apricot orange
+}
+
+func ExamplePairs() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	pairs, errf := Pairs(s.PrefixEntries([]byte("ap")))
+	for k, v := range pairs {
+		fmt.Printf("%s %s\n", k, v)
+	}
+
+	fmt.Println(errf())
+
+	// A table read from a plain io.Reader can be scanned only once.
+	// All stops silently on the second scan, but Pairs reports why.
+	b, _ := os.ReadFile(s.reader.(*os.File).Name())
+	stream, _ := NewSSTable(io.MultiReader(bytes.NewReader(b)))
+
+	n := 0
+	for range stream.All() {
+		n++
+	}
+
+	for range stream.All() {
+		n++
+	}
+
+	fmt.Println(n)
+
+	pairs, errf = Pairs(stream.Entries(nil, nil))
+	for k := range pairs {
+		fmt.Printf("%s\n", k)
+	}
+
+	fmt.Println(errors.Is(errf(), ErrCursorInUse))
+	// Output:
+	// apple red
+	// apricot orange
+	// <nil>
+	// 4
+	// true
+}
+
+func ExampleSeq() {
+	entry := Entry{Key: []byte("key1"), Value: []byte("value1")}
+	data, _ := entry.MarshalBinary()
+	data = append(data, data[:len(data)-2]...)
+
+	for e, err := range Seq(NewRecordIOReader(bytes.NewReader(data), uint64(len(data)))) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			break
+		}
+
+		fmt.Printf("%s %s\n", e.Key, e.Value)
+	}
+	// Output:
+	// key1 value1
+	// Error: unexpected EOF
+}
+
+func ExampleNewSeqCursor() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	c := NewSeqCursor(s.Entries([]byte("b"), nil))
+	defer c.Close()
+
+	for ; !c.Done(); c.Next() {
+		fmt.Printf("%s\n", c.Entry().Key)
+	}
+
+	fmt.Println("Error:", c.Err())
+
+	failing := func(yield func(*Entry, error) bool) {
+		if yield(&Entry{Key: []byte("first")}, nil) {
+			yield(nil, errors.New("broken"))
+		}
+	}
+
+	c = NewSeqCursor(failing)
+	for ; !c.Done(); c.Next() {
+		fmt.Printf("%s\n", c.Entry().Key)
+	}
+
+	fmt.Println("Error:", c.Err())
+	// Output:
+	// banana
+	// cherry
+	// Error: <nil>
+	// first
+	// Error: broken
+}
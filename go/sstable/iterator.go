@@ -0,0 +1,316 @@
+package sstable
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Iterator is a bidirectional Cursor over an SSTable. It reads the
+// table block by block with the index, so it only reads the blocks
+// that it visits in either direction. It decodes prefix compressed
+// blocks one restart interval at a time, and seeks in them by binary
+// searching the restart points.
+type Iterator struct {
+	table         *SSTable
+	reader        io.ReaderAt
+	skipChecksums bool
+	block         int
+	// prefix is the current block if it is prefix compressed, in which
+	// case entries hold its restart intervals from first to last
+	// exclusive.
+	prefix      *prefixBlock
+	first, last int
+	entries     []*Entry
+	pos         int
+	valid       bool
+	err         error
+}
+
+// NewIterator returns an Iterator positioned at the first entry of the
+// SSTable. It requires the reader to be an io.ReaderAt.
+func (s *SSTable) NewIterator() (*Iterator, error) {
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, fmt.Errorf("SSTable.NewIterator: %w", ErrNotRandomAccess)
+	}
+
+	it := &Iterator{table: s, reader: r, block: -1}
+	it.SeekToFirst()
+
+	return it, nil
+}
+
+// load reads the i-th block into the iterator. A prefix compressed
+// block is left undecoded for loadInterval. It returns false if the
+// block doesn't exist or reading fails.
+func (it *Iterator) load(i int) bool {
+	it.valid = false
+
+	if it.err != nil || i < 0 || i >= it.table.numBlocks() {
+		return false
+	}
+
+	if i == it.block {
+		return true
+	}
+
+	b, err := it.table.readBlock(it.reader, i, !it.skipChecksums)
+	if err != nil {
+		it.err = err
+		return false
+	}
+
+	format := blockFormatOf(it.table.header.version)
+
+	if encoding, raw, err := format.encoding(b); err == nil && encoding == prefixEncoding {
+		pb, err := parsePrefixBlock(raw, it.table.limits)
+		if err != nil {
+			it.err, it.block = err, -1
+			return false
+		}
+
+		it.block, it.prefix, it.entries = i, &pb, it.entries[:0]
+
+		return true
+	}
+
+	entries, err := format.entries(b, it.entries[:0], it.table.limits)
+	if err != nil {
+		it.err, it.block = err, -1
+		return false
+	}
+
+	entries = it.clone(entries)
+
+	ref, err := format.blobRef(b)
+	if err == nil && ref != nil {
+		entries[0].Value, err = it.table.readValue(it.reader, ref)
+	}
+
+	if err != nil {
+		it.err, it.block = err, -1
+		return false
+	}
+
+	it.block, it.prefix, it.entries = i, nil, entries
+
+	return true
+}
+
+// loadInterval decodes the r-th restart interval of the current prefix
+// compressed block as the only entries of the iterator.
+func (it *Iterator) loadInterval(r int) bool {
+	if it.prefix == nil {
+		return true
+	}
+
+	entries, err := it.prefix.interval(r, it.entries[:0])
+	if err != nil {
+		it.valid, it.err, it.block = false, err, -1
+		return false
+	}
+
+	it.first, it.last, it.entries = r, r+1, it.clone(entries)
+
+	return true
+}
+
+// extend decodes the restart interval after the entries of the iterator
+// if forward is true, or the one before them otherwise. It returns
+// false if there is none.
+func (it *Iterator) extend(forward bool) bool {
+	if it.prefix == nil || forward && it.last >= it.prefix.numRestarts() || !forward && it.first == 0 {
+		return false
+	}
+
+	r := it.first - 1
+	if forward {
+		r = it.last
+	}
+
+	entries, err := it.prefix.interval(r, nil)
+	if err != nil {
+		it.valid, it.err, it.block = false, err, -1
+		return false
+	}
+
+	entries = it.clone(entries)
+
+	if forward {
+		it.entries, it.last = append(it.entries, entries...), it.last+1
+	} else {
+		it.entries, it.first, it.pos = append(entries, it.entries...), it.first-1, it.pos+len(entries)
+	}
+
+	return true
+}
+
+// clone copies the entries unless the SSTable reads them zero-copy.
+func (it *Iterator) clone(entries []*Entry) []*Entry {
+	if !it.table.zeroCopy() {
+		for j, e := range entries {
+			entries[j] = e.clone()
+		}
+	}
+
+	return entries
+}
+
+// loadFirst loads the first entry of the i-th block.
+func (it *Iterator) loadFirst(i int) {
+	if it.load(i) && it.loadInterval(0) {
+		it.setPos(0)
+	}
+}
+
+// loadLast loads the last entry of the i-th block.
+func (it *Iterator) loadLast(i int) {
+	if !it.load(i) {
+		return
+	}
+
+	if it.prefix != nil && !it.loadInterval(it.prefix.numRestarts()-1) {
+		return
+	}
+
+	it.setPos(len(it.entries) - 1)
+}
+
+// setPos points the iterator at the pos-th entry of the current block.
+func (it *Iterator) setPos(pos int) {
+	it.pos = pos
+	it.valid = pos >= 0 && pos < len(it.entries)
+}
+
+// SeekToFirst moves the iterator to the first entry.
+func (it *Iterator) SeekToFirst() {
+	it.loadFirst(0)
+}
+
+// SeekToLast moves the iterator to the last entry.
+func (it *Iterator) SeekToLast() {
+	it.loadLast(it.table.numBlocks() - 1)
+}
+
+// Seek moves the iterator to the first entry whose key is greater than
+// or equal to key.
+func (it *Iterator) Seek(key []byte) {
+	i, err := it.table.firstBlockIndexOf(it.reader, key)
+	if err != nil {
+		it.valid, it.err = false, err
+		return
+	}
+
+	if i == -1 {
+		i = 0
+	}
+
+	if !it.load(i) || !it.loadRestartBefore(key, false) {
+		return
+	}
+
+	for {
+		it.setPos(sort.Search(len(it.entries), func(j int) bool {
+			return it.table.cmp.Compare(it.entries[j].Key, key) >= 0
+		}))
+
+		if it.valid || !it.extend(true) {
+			break
+		}
+	}
+
+	if !it.valid && it.err == nil {
+		it.loadFirst(i + 1)
+	}
+}
+
+// SeekForPrev moves the iterator to the last entry whose key is less
+// than or equal to key.
+func (it *Iterator) SeekForPrev(key []byte) {
+	i, err := it.table.blockIndexOf(it.reader, key)
+	if err != nil {
+		it.valid, it.err = false, err
+		return
+	}
+
+	if !it.load(i) || !it.loadRestartBefore(key, true) {
+		return
+	}
+
+	it.setPos(sort.Search(len(it.entries), func(j int) bool {
+		return it.table.cmp.Compare(it.entries[j].Key, key) > 0
+	}) - 1)
+}
+
+// loadRestartBefore decodes the restart interval of the current prefix
+// compressed block that precedes the first restart point whose key is
+// greater than or equal to key, or greater than key if after is true.
+// The entry that a seek looks for is in that interval or at the start
+// of the next one.
+func (it *Iterator) loadRestartBefore(key []byte, after bool) bool {
+	if it.prefix == nil {
+		return true
+	}
+
+	r, err := it.prefix.searchRestarts(key, it.table.cmp, after)
+	if err != nil {
+		it.valid, it.err, it.block = false, err, -1
+		return false
+	}
+
+	return it.loadInterval(max(r-1, 0))
+}
+
+// Entry returns the current entry. It returns nil if the iterator is
+// done.
+func (it *Iterator) Entry() *Entry {
+	if !it.valid {
+		return nil
+	}
+
+	return it.entries[it.pos]
+}
+
+// Done returns true when the iterator moved past either end of the
+// SSTable or failed to read a block.
+func (it *Iterator) Done() bool {
+	return !it.valid
+}
+
+// Next moves the iterator to the next entry.
+func (it *Iterator) Next() {
+	if !it.valid {
+		return
+	}
+
+	if it.pos+1 < len(it.entries) || it.extend(true) {
+		it.setPos(it.pos + 1)
+		return
+	}
+
+	if it.err == nil {
+		it.loadFirst(it.block + 1)
+	}
+}
+
+// Prev moves the iterator to the previous entry.
+func (it *Iterator) Prev() {
+	if !it.valid {
+		return
+	}
+
+	if it.pos > 0 || it.extend(false) {
+		it.setPos(it.pos - 1)
+		return
+	}
+
+	if it.err == nil {
+		it.loadLast(it.block - 1)
+	}
+}
+
+// Err returns the first error that the iterator encountered.
+func (it *Iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,47 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+)
+
+func ExampleIterator() {
+	// Each value is large enough to fill most of a block, so the
+	// iterator crosses block boundaries.
+	var entries []Entry
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		entries = append(entries, Entry{Key: []byte(key), Value: bytes.Repeat([]byte(key), 40000)})
+	}
+
+	s, cleanup := newExampleTable(entries)
+	defer cleanup()
+
+	it, err := s.NewIterator()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// The last 3 keys up to "cc".
+	it.SeekForPrev([]byte("cc"))
+	for n := 0; n < 3 && !it.Done(); n++ {
+		fmt.Printf("%s ", it.Entry().Key)
+		it.Prev()
+	}
+
+	fmt.Println(it.Err())
+
+	it.SeekToLast()
+	fmt.Printf("%s\n", it.Entry().Key)
+
+	it.Seek([]byte("bb"))
+	fmt.Printf("%s\n", it.Entry().Key)
+
+	it.SeekForPrev([]byte("0"))
+	fmt.Println(it.Done())
+	// Output:
+	// c b a <nil>
+	// e
+	// c
+	// true
+}
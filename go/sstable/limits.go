@@ -0,0 +1,128 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// ReadLimits bounds the lengths that an SSTable trusts before it
+// allocates memory for them, so that a corrupt or hostile file fails
+// with ErrCorrupt instead of exhausting the memory. Zero means no
+// limit. The size of the file bounds every length anyway if the reader
+// reports it, like *os.File, *bytes.Reader and *io.SectionReader do.
+type ReadLimits struct {
+	// MaxKeySize is the maximum length of a key.
+	MaxKeySize uint64
+	// MaxValueSize is the maximum length of a value.
+	MaxValueSize uint64
+	// MaxBlockSize is the maximum length of a block, both as stored and
+	// once decompressed.
+	MaxBlockSize uint64
+}
+
+// WithReadLimits makes the SSTable reject the keys, values and blocks
+// longer than the limits. The key and value limits that are zero
+// default to the MaxKeySize and MaxValueSize that the Writer recorded
+// in the Properties.
+func WithReadLimits(l ReadLimits) ReaderOption {
+	return func(s *SSTable) {
+		s.limits = l
+	}
+}
+
+// withDefaults returns l with the zero key and value limits replaced by
+// the ones that the Writer recorded in p.
+func (l ReadLimits) withDefaults(p *Properties) ReadLimits {
+	if l.MaxKeySize == 0 {
+		l.MaxKeySize = p.MaxKeySize
+	}
+
+	if l.MaxValueSize == 0 {
+		l.MaxValueSize = p.MaxValueSize
+	}
+
+	return l
+}
+
+// checkEntry returns ErrCorrupt if the key or the value is too long.
+func (l ReadLimits) checkEntry(keyLength, valueLength uint64) error {
+	if l.MaxKeySize > 0 && keyLength > l.MaxKeySize {
+		return fmt.Errorf("%w: key of %d bytes exceeds the limit of %d", ErrCorrupt, keyLength, l.MaxKeySize)
+	}
+
+	if l.MaxValueSize > 0 && valueLength > l.MaxValueSize {
+		return fmt.Errorf("%w: value of %d bytes exceeds the limit of %d", ErrCorrupt, valueLength, l.MaxValueSize)
+	}
+
+	return nil
+}
+
+// checkBlock returns ErrCorrupt if the block is too long.
+func (l ReadLimits) checkBlock(length uint64) error {
+	if l.MaxBlockSize > 0 && length > l.MaxBlockSize {
+		return fmt.Errorf("%w: block of %d bytes exceeds the limit of %d", ErrCorrupt, length, l.MaxBlockSize)
+	}
+
+	return nil
+}
+
+// readChunkSize is the number of bytes that readFull allocates at most
+// before it has read them.
+const readChunkSize = 1 << 20
+
+// readFull reads n bytes from r like io.ReadFull. Lengths above
+// readChunkSize are read into a buffer that grows as the bytes arrive,
+// so that a corrupt length can't make it allocate much more than r
+// holds.
+func readFull(r io.Reader, n uint64) ([]byte, error) {
+	if n <= readChunkSize {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+
+		return b, err
+	}
+
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("readFull: length %w: %d", ErrOffsetOverflow, n)
+	}
+
+	var buf bytes.Buffer
+
+	buf.Grow(readChunkSize)
+
+	m, err := io.CopyN(&buf, r, int64(n))
+	if err == io.EOF && m > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return buf.Bytes(), err
+}
+
+// readFullAt reads n bytes at offset from r like readFull.
+func readFullAt(r io.ReaderAt, offset, n uint64) ([]byte, error) {
+	if offset > math.MaxInt64 || n > math.MaxInt64-offset {
+		return nil, fmt.Errorf("readFullAt: %w: %d", ErrOffsetOverflow, offset)
+	}
+
+	return readFull(io.NewSectionReader(r, int64(offset), int64(n)), n) //nolint:gosec // overflow checked above
+}
+
+// sizeOf returns the size of the file that r reads, if r reports it.
+func sizeOf(r interface{}) (uint64, bool) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return uint64(max(r.Size(), 0)), true
+	case interface{ Stat() (os.FileInfo, error) }:
+		fi, err := r.Stat()
+		if err != nil || !fi.Mode().IsRegular() {
+			return 0, false
+		}
+
+		return uint64(max(fi.Size(), 0)), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,116 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+func ExampleWithReadLimits() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithBlockSize(16))
+	_ = w.Write(Entry{Key: []byte("apple"), Value: []byte("red")})
+	_ = w.Write(Entry{Key: bytes.Repeat([]byte("b"), 100), Value: []byte("yellow")})
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	_, err := NewSSTable(bytes.NewReader(b), WithReadLimits(ReadLimits{MaxKeySize: 64}))
+	fmt.Println(errors.Is(err, ErrCorrupt), err)
+
+	// The number of blocks in the header must match the index.
+	b[7]++
+	_, err = NewSSTable(bytes.NewReader(b))
+	fmt.Println(errors.Is(err, ErrCorrupt), err)
+	// Output:
+	// true sstable: corrupt table: key of 100 bytes exceeds the limit of 64
+	// true sstable: corrupt table: the index has 2 entries, not 3
+}
+
+func ExampleWithReadLimits_decompression() {
+	// A block of 16 MiB of zeros compresses to well within the limit.
+	write := func(opts ...WriterOption) []byte {
+		f, _ := os.CreateTemp("", "")
+
+		name := f.Name()
+		defer os.Remove(name)
+
+		w := NewWriter(f, opts...)
+		_ = w.Write(Entry{Key: []byte("zeros"), Value: make([]byte, 16<<20)})
+		w.Close()
+
+		b, _ := os.ReadFile(name)
+
+		return b
+	}
+
+	limits := ReadLimits{MaxValueSize: 64 << 10, MaxBlockSize: 1 << 20}
+
+	for _, codec := range []CodecID{FlateCompression, SnappyCompression} {
+		b := write(WithCompression(codec), WithBlockSize(64<<20))
+
+		s, _ := NewSSTable(bytes.NewReader(b), WithReadLimits(limits))
+		_, _, err := s.Get([]byte("zeros"))
+		fmt.Println(len(b) < 2<<20, errors.Is(err, ErrCorrupt), err)
+
+		stream, _ := NewSSTable(io.MultiReader(bytes.NewReader(b)), WithReadLimits(limits))
+		for _, err := range stream.Entries(nil, nil) {
+			fmt.Println(errors.Is(err, ErrCorrupt))
+		}
+	}
+
+	// The shared prefixes of prefix compressed keys make long keys
+	// cheap to store, but not to decode. The index holds only the short
+	// first key.
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithPrefixCompression(16), WithBlockSize(1<<20))
+	_ = w.Write(Entry{Key: []byte("a")})
+
+	for i := range 100 {
+		_ = w.Write(Entry{Key: fmt.Appendf(bytes.Repeat([]byte("b"), 4096), "%03d", i)})
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+	s, _ := NewSSTable(bytes.NewReader(b), WithReadLimits(ReadLimits{MaxKeySize: 1 << 10, MaxBlockSize: 1 << 20}))
+	_, _, err := s.Get([]byte("b"))
+	fmt.Println(errors.Is(err, ErrCorrupt), err)
+	// Output:
+	// true true failed to decompress the block at offset 16: sstable: corrupt table: block of 1048577 bytes exceeds the limit of 1048576
+	// true
+	// true true failed to decompress the block at offset 16: sstable: corrupt table: block of 16777229 bytes exceeds the limit of 1048576
+	// true
+	// true sstable: corrupt table: key of 4099 bytes exceeds the limit of 1024
+}
+
+func ExampleWithReadLimits_filter() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	// The bloom filter is a meta block, so the block limit bounds it too.
+	w := NewWriter(f, WithBloomFilter(10))
+	for i := range 1000 {
+		_ = w.Write(Entry{Key: fmt.Appendf(nil, "%04d", i)})
+	}
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	_, err := NewSSTable(bytes.NewReader(b), WithReadLimits(ReadLimits{MaxBlockSize: 1 << 10}))
+	fmt.Println(errors.Is(err, ErrCorrupt), err)
+	// Output:
+	// true failed to read the meta blocks: meta block "filter.bloom": sstable: corrupt table: block of 1251 bytes exceeds the limit of 1024
+}
@@ -0,0 +1,134 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// metaBlock is a named block of table-wide data such as a filter.
+type metaBlock struct {
+	name string
+	data []byte
+}
+
+// metaIndex is the list of meta blocks. It is written right after the
+// index as the number of meta blocks, the name length, data length and
+// name of each meta block, and then the data of each meta block in
+// the same order.
+type metaIndex []metaBlock
+
+// WriteTo implements the io.WriterTo interface.
+func (m metaIndex) WriteTo(w io.Writer) (n int64, err error) {
+	if len(m) > math.MaxUint32 {
+		return 0, errors.New("metaIndex.WriteTo: too many meta blocks")
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := binary.Write(buf, binary.BigEndian, uint32(len(m))); err != nil { //nolint:gosec // overflow checked above
+		return 0, err
+	}
+
+	for _, b := range m {
+		if len(b.name) > math.MaxUint32 {
+			return 0, errors.New("metaIndex.WriteTo: meta block name too large")
+		}
+
+		if err := binary.Write(buf, binary.BigEndian, uint32(len(b.name))); err != nil { //nolint:gosec // overflow checked above
+			return 0, err
+		}
+
+		if err := binary.Write(buf, binary.BigEndian, uint64(len(b.data))); err != nil {
+			return 0, err
+		}
+
+		buf.WriteString(b.name)
+	}
+
+	for _, b := range m {
+		buf.Write(b.data)
+	}
+
+	return buf.WriteTo(w)
+}
+
+// ReadFrom implements the io.ReaderFrom interface.
+func (m *metaIndex) ReadFrom(r io.Reader) (n int64, err error) {
+	return m.readFrom(r, ReadLimits{})
+}
+
+// readFrom is ReadFrom that rejects the meta blocks longer than the
+// MaxBlockSize of the limits.
+func (m *metaIndex) readFrom(r io.Reader, limits ReadLimits) (n int64, err error) {
+	var countbuf [4]byte
+
+	nn, err := io.ReadFull(r, countbuf[:])
+	n += int64(nn)
+
+	if err != nil {
+		return n, err
+	}
+
+	count := binary.BigEndian.Uint32(countbuf[:])
+	lengths := make([]uint64, 0, min(count, 64))
+
+	for range count {
+		var lenbuf [12]byte
+
+		nn, err := io.ReadFull(r, lenbuf[:])
+		n += int64(nn)
+
+		if err != nil {
+			return n, err
+		}
+
+		name, err := readFull(r, uint64(binary.BigEndian.Uint32(lenbuf[:4])))
+		n += int64(len(name))
+
+		if err != nil {
+			return n, err
+		}
+
+		length := binary.BigEndian.Uint64(lenbuf[4:])
+		if err := limits.checkBlock(length); err != nil {
+			return n, fmt.Errorf("meta block %q: %w", name, err)
+		}
+
+		*m = append(*m, metaBlock{name: string(name)})
+		lengths = append(lengths, length)
+	}
+
+	for i, length := range lengths {
+		data := bytes.NewBuffer([]byte{})
+
+		nn, err := io.CopyN(data, r, int64(min(length, math.MaxInt64))) //nolint:gosec // clamped above
+		n += nn
+
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		(*m)[len(*m)-len(lengths)+i].data = data.Bytes()
+	}
+
+	return n, nil
+}
+
+// find returns the data of the meta block with the name. It returns
+// nil if there is no such meta block.
+func (m metaIndex) find(name string) []byte {
+	for _, b := range m {
+		if b.name == name {
+			return b.data
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package sstable
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// mmapReader is an io.ReaderAt over a memory-mapped file.
+type mmapReader struct {
+	data  []byte
+	unmap func([]byte) error
+}
+
+// ReadAt implements the io.ReaderAt interface.
+func (m *mmapReader) ReadAt(p []byte, off int64) (int, error) {
+	if m.data == nil {
+		return 0, errors.New("mmapReader.ReadAt: closed")
+	}
+
+	if off < 0 {
+		return 0, errors.New("mmapReader.ReadAt: negative offset")
+	}
+
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Size returns the size of the mapped file.
+func (m *mmapReader) Size() int64 {
+	return int64(len(m.data))
+}
+
+// slice returns length bytes at offset without copying.
+func (m *mmapReader) slice(offset, length uint64) ([]byte, error) {
+	if m.data == nil {
+		return nil, errors.New("mmapReader.slice: closed")
+	}
+
+	if offset > math.MaxInt || length > uint64(len(m.data)) || offset > uint64(len(m.data))-length {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	start, end := int(offset), int(offset+length) //nolint:gosec // bounded by len(m.data) above
+
+	return m.data[start:end:end], nil
+}
+
+// Close unmaps the file.
+func (m *mmapReader) Close() error {
+	if m.data == nil {
+		return nil
+	}
+
+	data := m.data
+	m.data = nil
+
+	return m.unmap(data)
+}
+
+// OpenMmap opens the SSTable file at path by mapping it into memory.
+// Entries read from the SSTable point straight into the mapping
+// instead of being copied: they must not be modified, and they are only
+// valid until Close unmaps the file. Copy keys and values that need to
+// outlive the SSTable.
+func OpenMmap(path string, opts ...ReaderOption) (*SSTable, error) {
+	m, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSSTable(m, opts...)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	s.closer = m
+
+	return s, nil
+}
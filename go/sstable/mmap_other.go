@@ -0,0 +1,12 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package sstable
+
+import (
+	"errors"
+)
+
+// mmapFile is not supported on this platform.
+func mmapFile(string) (*mmapReader, error) {
+	return nil, errors.New("OpenMmap: not supported on this platform")
+}
@@ -0,0 +1,51 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package sstable
+
+import (
+	"fmt"
+	"os"
+)
+
+func ExampleOpenMmap() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f)
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	s, err := OpenMmap(name)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	value, found, err := s.Get([]byte("banana"))
+	fmt.Println(string(value), found, err)
+
+	// Keys and values point into the mapping, so copy the ones that
+	// are needed after Close.
+	var keys []string
+	for k := range s.All() {
+		keys = append(keys, string(k))
+	}
+
+	fmt.Println(s.Close())
+	fmt.Println(keys)
+
+	_, _, err = s.Get([]byte("banana"))
+	fmt.Println(err)
+	// Output:
+	// yellow true <nil>
+	// <nil>
+	// [apple apricot banana cherry]
+	// mmapReader.slice: closed
+}
@@ -0,0 +1,40 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package sstable
+
+import (
+	"errors"
+	"math"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the file at path into memory read only.
+func mmapFile(path string) (*mmapReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if size > math.MaxInt {
+		return nil, errors.New("mmapFile: file too large")
+	}
+
+	if size == 0 {
+		return &mmapReader{data: []byte{}, unmap: func([]byte) error { return nil }}, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED) //nolint:gosec // fd and size fit in int
+	if err != nil {
+		return nil, err
+	}
+
+	return &mmapReader{data: data, unmap: syscall.Munmap}, nil
+}
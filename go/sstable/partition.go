@@ -0,0 +1,198 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// partitionsMetaBlockName is the name of the meta block that describes
+// the partitions of a partitioned index.
+const partitionsMetaBlockName = "index.partitions"
+
+// defaultBlocksPerPartition is the number of index entries in a
+// partition of a partitioned index.
+const defaultBlocksPerPartition = 128
+
+// partitions describes a partitioned index. Every partition holds the
+// index entries of blocksPerPartition data blocks except the last one,
+// which holds the rest.
+type partitions struct {
+	blocksPerPartition uint32
+	numBlocks          uint64
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (p *partitions) MarshalBinary() ([]byte, error) {
+	data := binary.BigEndian.AppendUint32(nil, p.blocksPerPartition)
+	return binary.BigEndian.AppendUint64(data, p.numBlocks), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (p *partitions) UnmarshalBinary(data []byte) error {
+	if len(data) != 12 {
+		return fmt.Errorf("partitions.UnmarshalBinary: invalid length: %w", ErrCorrupt)
+	}
+
+	p.blocksPerPartition = binary.BigEndian.Uint32(data[:4])
+	p.numBlocks = binary.BigEndian.Uint64(data[4:])
+
+	if p.blocksPerPartition == 0 {
+		return fmt.Errorf("partitions.UnmarshalBinary: no blocks per partition: %w", ErrCorrupt)
+	}
+
+	return nil
+}
+
+// numBlocks returns the number of the data blocks.
+func (s *SSTable) numBlocks() int {
+	if s.partitions == nil {
+		return len(s.index)
+	}
+
+	return int(s.partitions.numBlocks) //nolint:gosec // bounded by the partitions in the index
+}
+
+// blockEntry returns the index entry of the i-th data block. It reads
+// the partition of the block if the index is partitioned.
+func (s *SSTable) blockEntry(r io.ReaderAt, i int) (indexEntry, error) {
+	if s.partitions == nil {
+		return s.index[i], nil
+	}
+
+	n := int(s.partitions.blocksPerPartition)
+
+	partition, err := s.readPartition(r, i/n)
+	if err != nil {
+		return indexEntry{}, err
+	}
+
+	if i%n >= len(partition) {
+		return indexEntry{}, fmt.Errorf("SSTable.blockEntry: partition of block %d too short: %w", i, ErrCorrupt)
+	}
+
+	return partition[i%n], nil
+}
+
+// blockIndexOf returns the index of the data block that might contain
+// the key. It returns -1 if there is no such block.
+func (s *SSTable) blockIndexOf(r io.ReaderAt, key []byte) (int, error) {
+	p := s.index.entryIndexOf(key, s.cmp)
+	if s.partitions == nil || p == -1 {
+		return p, nil
+	}
+
+	partition, err := s.readPartition(r, p)
+	if err != nil {
+		return 0, err
+	}
+
+	return p*int(s.partitions.blocksPerPartition) + partition.entryIndexOf(key, s.cmp), nil
+}
+
+// firstBlockIndexOf returns the index of the first data block that
+// might contain the key, even if equal keys span blocks. It returns -1
+// if the key is before the first block.
+func (s *SSTable) firstBlockIndexOf(r io.ReaderAt, key []byte) (int, error) {
+	p := s.index.entryIndexBefore(key, s.cmp)
+	if s.partitions == nil || p == -1 {
+		return p, nil
+	}
+
+	partition, err := s.readPartition(r, p)
+	if err != nil {
+		return 0, err
+	}
+
+	return p*int(s.partitions.blocksPerPartition) + partition.entryIndexBefore(key, s.cmp), nil
+}
+
+// decodedPartition is an index partition that readPartition decoded.
+type decodedPartition struct {
+	p       int
+	entries index
+}
+
+// readPartition returns the p-th partition of the index. It keeps the
+// last partition that it decoded, so that reading the blocks in order
+// decodes each partition once, and shares it between the goroutines
+// that read the SSTable. The returned partition must not be modified.
+func (s *SSTable) readPartition(r io.ReaderAt, p int) (index, error) {
+	if last := s.partition.Load(); last != nil && last.p == p {
+		return last.entries, nil
+	}
+
+	partition, err := s.decodePartition(r, p)
+	if err != nil {
+		return nil, err
+	}
+
+	s.partition.Store(&decodedPartition{p: p, entries: partition})
+
+	return partition, nil
+}
+
+// decodePartition reads and decodes the p-th partition of the index. It
+// keeps the partition in the block cache if the SSTable has one.
+func (s *SSTable) decodePartition(r io.ReaderAt, p int) (index, error) {
+	if p < 0 || p >= len(s.index) {
+		return nil, fmt.Errorf("SSTable.readPartition: no partition %d: %w", p, ErrCorrupt)
+	}
+
+	e := s.index[p]
+
+	var (
+		key  blockCacheKey
+		data block
+		ok   bool
+	)
+
+	if s.cache != nil {
+		key = blockCacheKey{table: s.id, offset: e.blockOffset}
+		data, ok = s.cache.get(key)
+	}
+
+	if !ok {
+		if err := s.limits.checkBlock(uint64(e.blockLength)); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if data, err = readBlock(r, e); err != nil {
+			return nil, fmt.Errorf("failed to read the index partition: %w", err)
+		}
+
+		if s.header.version >= versionChecksums {
+			if len(data) < checksumSize {
+				return nil, fmt.Errorf("SSTable.readPartition: truncated partition at offset %d: %w", e.blockOffset, ErrCorrupt)
+			}
+
+			stored := data[len(data)-checksumSize:]
+			if data = data[:len(data)-checksumSize]; binary.BigEndian.Uint32(stored) != checksum(data) {
+				return nil, &CorruptionError{Offset: e.blockOffset, What: "index partition"}
+			}
+		}
+
+		if s.cache != nil {
+			s.cache.add(key, data)
+		}
+	}
+
+	n := uint64(s.partitions.blocksPerPartition)
+	if rest := s.partitions.numBlocks - uint64(p)*n; rest < n { //nolint:gosec // p is non-negative
+		n = rest
+	}
+
+	var partition index
+	if err := partition.readN(bytes.NewReader(data), uint32(n)); err != nil { //nolint:gosec // bounded by blocksPerPartition
+		return nil, fmt.Errorf("failed to read the index partition: %w", err)
+	}
+
+	// The data blocks end before the first partition.
+	if err := partition.check(s.index[0].blockOffset, s.limits); err != nil {
+		return nil, err
+	}
+
+	return partition, nil
+}
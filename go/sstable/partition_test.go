@@ -0,0 +1,96 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+)
+
+func ExampleWithPartitionedIndex() {
+	var buf bytes.Buffer
+
+	// Tiny blocks and partitions to have a few of them.
+	w := NewWriter(&buf, WithPartitionedIndex(2), WithChecksums(), WithBlockSize(1))
+
+	for i := range 7 {
+		key := fmt.Sprintf("key%d", i)
+		if err := w.Write(Entry{Key: []byte(key), Value: []byte{byte(i)}}); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		fmt.Println(err)
+	}
+
+	cache := NewBlockCache(1 << 20)
+
+	s, err := NewSSTable(bytes.NewReader(buf.Bytes()), WithBlockCache(cache))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Only the index of the partitions is in memory.
+	fmt.Println(len(s.index), s.numBlocks(), s.Properties().NumBlocks)
+
+	for _, key := range []string{"key0", "key3", "key6", "key7", "a"} {
+		value, found, err := s.Get([]byte(key))
+		fmt.Println(key, value, found, err)
+	}
+
+	it, err := s.NewIterator()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for it.SeekToLast(); !it.Done(); it.Prev() {
+		fmt.Printf("%s ", it.Entry().Key)
+	}
+
+	fmt.Println(it.Err())
+	// Output:
+	// 4 7 7
+	// key0 [0] true <nil>
+	// key3 [3] true <nil>
+	// key6 [6] true <nil>
+	// key7 [] false <nil>
+	// a [] false <nil>
+	// key6 key5 key4 key3 key2 key1 key0 <nil>
+}
+
+func ExampleWithPartitionedIndex_reads() {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, WithPartitionedIndex(16), WithBlockSize(1))
+	for i := range 64 {
+		_ = w.Write(Entry{Key: fmt.Appendf(nil, "key%02d", i), Value: []byte{byte(i)}})
+	}
+
+	w.Close()
+
+	r := &countingReaderAt{Reader: bytes.NewReader(buf.Bytes())}
+
+	s, err := NewSSTable(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Without a block cache, reading the 64 blocks in order reads each
+	// of the 4 partitions once.
+	r.reads = 0
+	n := 0
+
+	for range s.All() {
+		n++
+	}
+
+	fmt.Println(n, r.reads)
+
+	problems, _ := Verify(r, VerifyOptions{})
+	fmt.Println(problems)
+	// Output:
+	// 64 68
+	// []
+}
@@ -0,0 +1,245 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// defaultRestartInterval is the number of entries between the restart
+// points of prefix compressed blocks.
+const defaultRestartInterval = 16
+
+// A prefix compressed block is a sequence of entries followed by the
+// offsets of the restart points and the number of the restart points,
+// each as a big endian uint32. An entry is the uvarints of the length
+// of the prefix shared with the previous key, the length of the rest of
+// the key and the length of the value, followed by the rest of the key
+// and the value. Keys at the restart points share nothing, so a seek
+// can binary search them.
+
+// errPrefixBlockCorrupt is returned when a prefix compressed block
+// can't be decoded.
+var errPrefixBlockCorrupt = fmt.Errorf("prefixBlock: corrupt block: %w", ErrCorrupt)
+
+// prefixBlockBuilder builds a prefix compressed block.
+type prefixBlockBuilder struct {
+	restartInterval int
+	buf             []byte
+	restarts        []uint32
+	count           int
+	lastKey         []byte
+}
+
+// add appends the entry to the block.
+func (b *prefixBlockBuilder) add(key, value []byte) {
+	shared := 0
+	if b.count%b.restartInterval == 0 {
+		b.restarts = append(b.restarts, uint32(len(b.buf))) //nolint:gosec // blocks are far smaller than 4 GiB
+	} else {
+		for shared < len(key) && shared < len(b.lastKey) && key[shared] == b.lastKey[shared] {
+			shared++
+		}
+	}
+
+	b.buf = binary.AppendUvarint(b.buf, uint64(shared))
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(key)-shared))
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(value)))
+	b.buf = append(b.buf, key[shared:]...)
+	b.buf = append(b.buf, value...)
+	b.lastKey = append(b.lastKey[:0], key...)
+	b.count++
+}
+
+// len returns the number of entries in the block.
+func (b *prefixBlockBuilder) len() int {
+	return b.count
+}
+
+// finish appends the restart points and returns the block. The block
+// is valid until the next call to reset.
+func (b *prefixBlockBuilder) finish() []byte {
+	for _, r := range b.restarts {
+		b.buf = binary.BigEndian.AppendUint32(b.buf, r)
+	}
+
+	return binary.BigEndian.AppendUint32(b.buf, uint32(len(b.restarts))) //nolint:gosec // blocks are far smaller than 4 GiB
+}
+
+// reset empties the builder for the next block.
+func (b *prefixBlockBuilder) reset() {
+	b.buf, b.restarts, b.count, b.lastKey = b.buf[:0], b.restarts[:0], 0, b.lastKey[:0]
+}
+
+// prefixBlock is a decoded view of a prefix compressed block.
+type prefixBlock struct {
+	data     []byte
+	restarts []byte
+	limits   ReadLimits
+}
+
+// parsePrefixBlock splits the raw block into the entries and the
+// restart points. The entries are decoded within the limits.
+func parsePrefixBlock(b block, limits ReadLimits) (prefixBlock, error) {
+	if len(b) < 4 {
+		return prefixBlock{}, errPrefixBlockCorrupt
+	}
+
+	n := uint64(binary.BigEndian.Uint32(b[len(b)-4:]))
+	if n == 0 || n > uint64(len(b)-4)/4 {
+		return prefixBlock{}, errPrefixBlockCorrupt
+	}
+
+	start := len(b) - 4 - int(n)*4 //nolint:gosec // bounded by len(b) above
+
+	return prefixBlock{data: b[:start], restarts: b[start : len(b)-4], limits: limits}, nil
+}
+
+// numRestarts returns the number of the restart points.
+func (b prefixBlock) numRestarts() int {
+	return len(b.restarts) / 4
+}
+
+// restart returns the offset of the i-th restart point.
+func (b prefixBlock) restart(i int) int {
+	return int(binary.BigEndian.Uint32(b.restarts[i*4:]))
+}
+
+// entryAt decodes the entry at offset that follows the entry of the
+// key prev. It returns the entry and the offset of the next entry. The
+// key is newly allocated and the value points into the block.
+func (b prefixBlock) entryAt(offset int, prev []byte) (*Entry, int, error) {
+	if offset < 0 || offset >= len(b.data) {
+		return nil, 0, errPrefixBlockCorrupt
+	}
+
+	var lengths [3]uint64
+
+	p := b.data[offset:]
+	for i := range lengths {
+		v, n := binary.Uvarint(p)
+		if n <= 0 {
+			return nil, 0, errPrefixBlockCorrupt
+		}
+
+		lengths[i], p = v, p[n:]
+	}
+
+	shared, unshared, valueLength := lengths[0], lengths[1], lengths[2]
+	if shared > uint64(len(prev)) || unshared > uint64(len(p)) || valueLength > uint64(len(p))-unshared {
+		return nil, 0, errPrefixBlockCorrupt
+	}
+
+	// The shared prefixes let a small block hold many long keys, so the
+	// key length is checked before the key is allocated.
+	if err := b.limits.checkEntry(shared+unshared, valueLength); err != nil {
+		return nil, 0, err
+	}
+
+	key := make([]byte, 0, shared+unshared)
+	key = append(key, prev[:shared]...)
+	key = append(key, p[:unshared]...)
+	value := p[unshared : unshared+valueLength : unshared+valueLength]
+	next := len(b.data) - len(p) + int(unshared+valueLength) //nolint:gosec // bounded by len(p) above
+
+	return &Entry{Key: key, Value: value}, next, nil
+}
+
+// entries decodes all the entries of the block.
+func (b prefixBlock) entries(dst []*Entry) ([]*Entry, error) {
+	var prev []byte
+
+	for offset := 0; offset < len(b.data); {
+		e, next, err := b.entryAt(offset, prev)
+		if err != nil {
+			return nil, err
+		}
+
+		dst = append(dst, e)
+		prev, offset = e.Key, next
+	}
+
+	return dst, nil
+}
+
+// searchRestarts returns the index of the first restart point whose
+// key is greater than or equal to key in the order of cmp, or greater
+// than key if after is true. It returns numRestarts if there is none.
+func (b prefixBlock) searchRestarts(key []byte, cmp Comparer, after bool) (int, error) {
+	var err error
+
+	i := sort.Search(b.numRestarts(), func(i int) bool {
+		if err != nil {
+			return true
+		}
+
+		var e *Entry
+
+		e, _, err = b.entryAt(b.restart(i), nil)
+		if err != nil {
+			return true
+		}
+
+		if after {
+			return cmp.Compare(e.Key, key) > 0
+		}
+
+		return cmp.Compare(e.Key, key) >= 0
+	})
+
+	return i, err
+}
+
+// interval decodes the entries from the i-th restart point to the next
+// one and appends them to dst.
+func (b prefixBlock) interval(i int, dst []*Entry) ([]*Entry, error) {
+	end := len(b.data)
+	if i+1 < b.numRestarts() {
+		end = b.restart(i + 1)
+	}
+
+	var prev []byte
+
+	for offset := b.restart(i); offset < end; {
+		e, next, err := b.entryAt(offset, prev)
+		if err != nil {
+			return nil, err
+		}
+
+		dst = append(dst, e)
+		prev, offset = e.Key, next
+	}
+
+	return dst, nil
+}
+
+// find returns the entry of the key in the order of cmp. It returns nil
+// if the key isn't in the block. It binary searches the restart points
+// and scans only the entries that follow the last restart point whose
+// key is less than the key, so that it finds the first of equal keys.
+func (b prefixBlock) find(key []byte, cmp Comparer) (*Entry, error) {
+	i, err := b.searchRestarts(key, cmp, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev []byte
+
+	for offset := b.restart(max(i-1, 0)); offset < len(b.data); {
+		e, next, err := b.entryAt(offset, prev)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c := cmp.Compare(e.Key, key); {
+		case c == 0:
+			return e, nil
+		case c > 0:
+			return nil, nil
+		}
+
+		prev, offset = e.Key, next
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,120 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+func ExampleWithPrefixCompression() {
+	write := func(opts ...WriterOption) []byte {
+		var buf bytes.Buffer
+
+		w := NewWriter(&buf, opts...)
+		for i := range 100 {
+			key := fmt.Sprintf("https://example.com/fruits/%03d", i)
+			if err := w.Write(Entry{Key: []byte(key), Value: []byte{byte(i)}}); err != nil {
+				fmt.Println(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			fmt.Println(err)
+		}
+
+		return buf.Bytes()
+	}
+
+	plain, prefixed := write(WithCompression(NoCompression)), write(WithPrefixCompression(4))
+	fmt.Println(len(prefixed) < len(plain)/2)
+
+	s, err := NewSSTable(bytes.NewReader(prefixed))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, key := range []string{"https://example.com/fruits/000", "https://example.com/fruits/042", "https://example.com/fruits/043a"} {
+		value, found, err := s.Get([]byte(key))
+		fmt.Println(value, found, err)
+	}
+
+	for key, value := range s.Range([]byte("https://example.com/fruits/097"), nil) {
+		fmt.Printf("%s %d\n", key, value)
+	}
+
+	// Readers that aren't random access decode the blocks in order.
+	s, err = NewSSTable(io.MultiReader(bytes.NewReader(prefixed)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	n := 0
+	for _, err := range Seq(s.ScanFrom(nil)) {
+		if err != nil {
+			fmt.Println(err)
+		}
+
+		n++
+	}
+
+	fmt.Println(n)
+	// Output:
+	// true
+	// [0] true <nil>
+	// [42] true <nil>
+	// [] false <nil>
+	// https://example.com/fruits/097 [97]
+	// https://example.com/fruits/098 [98]
+	// https://example.com/fruits/099 [99]
+	// 100
+}
+
+func ExampleIterator_restarts() {
+	for _, blockSize := range []int{4096, 64} {
+		var buf bytes.Buffer
+
+		w := NewWriter(&buf, WithPrefixCompression(3), WithBlockSize(blockSize))
+		for i := range 20 {
+			for j := range 2 {
+				_ = w.Write(Entry{Key: fmt.Appendf(nil, "k%02d", i), Value: []byte{byte(j)}})
+			}
+		}
+
+		w.Close()
+
+		s, _ := NewSSTable(bytes.NewReader(buf.Bytes()))
+		it, _ := s.NewIterator()
+
+		it.Seek([]byte("k07"))
+		fmt.Printf("%s %d ", it.Entry().Key, it.Entry().Value)
+		it.Prev()
+		fmt.Printf("%s %d ", it.Entry().Key, it.Entry().Value)
+
+		it.SeekForPrev([]byte("k07"))
+		fmt.Printf("%s %d ", it.Entry().Key, it.Entry().Value)
+		it.Next()
+		fmt.Printf("%s %d ", it.Entry().Key, it.Entry().Value)
+
+		it.Seek([]byte("k10a"))
+		fmt.Printf("%s %d ", it.Entry().Key, it.Entry().Value)
+
+		it.Seek([]byte("k99"))
+		fmt.Print(it.Done(), " ")
+
+		n := 0
+		for it.SeekToLast(); !it.Done(); it.Prev() {
+			n++
+		}
+
+		for it.Seek([]byte("k05")); !it.Done(); it.Next() {
+			n++
+		}
+
+		fmt.Println(n, it.Err())
+	}
+	// Output:
+	// k07 [0] k06 [1] k07 [1] k08 [0] k11 [0] true 70 <nil>
+	// k07 [0] k06 [1] k07 [1] k08 [0] k11 [0] true 70 <nil>
+}
@@ -0,0 +1,258 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// propertiesMetaBlockName is the name of the meta block that holds the
+// properties of the table.
+const propertiesMetaBlockName = "properties"
+
+// reservedPropertyPrefix is the prefix of the names of the properties
+// that the Writer collects. User properties can't use it.
+const reservedPropertyPrefix = "sstable."
+
+// Names of the properties that the Writer collects.
+const (
+	propNumEntries       = reservedPropertyPrefix + "num_entries"
+	propNumBlocks        = reservedPropertyPrefix + "num_blocks"
+	propSmallestKey      = reservedPropertyPrefix + "smallest_key"
+	propLargestKey       = reservedPropertyPrefix + "largest_key"
+	propRawKeySize       = reservedPropertyPrefix + "raw_key_size"
+	propRawValueSize     = reservedPropertyPrefix + "raw_value_size"
+	propRawDataSize      = reservedPropertyPrefix + "raw_data_size"
+	propDataSize         = reservedPropertyPrefix + "data_size"
+	propFormatVersion    = reservedPropertyPrefix + "format_version"
+	propCreationTime     = reservedPropertyPrefix + "creation_time"
+	propComparer         = reservedPropertyPrefix + "comparer"
+	propBlockSize        = reservedPropertyPrefix + "block_size"
+	propRestartInterval  = reservedPropertyPrefix + "restart_interval"
+	propFilterBitsPerKey = reservedPropertyPrefix + "filter_bits_per_key"
+	propMaxKeySize       = reservedPropertyPrefix + "max_key_size"
+	propMaxValueSize     = reservedPropertyPrefix + "max_value_size"
+	propDuplicates       = reservedPropertyPrefix + "duplicates"
+)
+
+// Properties are the statistics of a table and the user properties
+// that the Writer recorded.
+type Properties struct {
+	// NumEntries is the number of entries.
+	NumEntries uint64
+	// NumBlocks is the number of data blocks.
+	NumBlocks uint64
+	// SmallestKey and LargestKey are the first and the last keys. They
+	// are nil if the table is empty.
+	SmallestKey []byte
+	LargestKey  []byte
+	// RawKeySize and RawValueSize are the total bytes of the keys and
+	// the values.
+	RawKeySize   uint64
+	RawValueSize uint64
+	// RawDataSize is the bytes of the data blocks before compression
+	// and DataSize is the bytes of the data blocks in the file.
+	RawDataSize uint64
+	DataSize    uint64
+	// FormatVersion is the format version of the file.
+	FormatVersion uint32
+	// CreationTime is when the Writer was closed.
+	CreationTime time.Time
+	// Comparer is the name of the Comparer that orders the keys.
+	Comparer string
+	// BlockSize is the target number of bytes of entries in a data
+	// block.
+	BlockSize uint64
+	// RestartInterval is the number of entries between the restart
+	// points of prefix compressed blocks. It is zero if the keys aren't
+	// prefix compressed.
+	RestartInterval uint64
+	// FilterBitsPerKey is the bits for each key of the bloom filter. It
+	// is zero if the table has no filter.
+	FilterBitsPerKey uint64
+	// MaxKeySize and MaxValueSize are the limits that the Writer
+	// enforced. Zero means no limit. The SSTable applies them as its
+	// ReadLimits unless WithReadLimits sets them.
+	MaxKeySize   uint64
+	MaxValueSize uint64
+	// Duplicates is the policy of the Writer for entries of the same
+	// key. Only tables of AllowDuplicates can have multiple values for
+	// a key, so GetAll returns at most one value of other tables, and
+	// Verify reports their repeated keys.
+	Duplicates DuplicatePolicy
+	// User holds the properties set by Writer.SetProperty.
+	User map[string]string
+}
+
+// add accumulates the statistics of the entry.
+func (p *Properties) add(e *Entry) {
+	if p.NumEntries == 0 {
+		p.SmallestKey = append([]byte{}, e.Key...)
+	}
+
+	p.NumEntries++
+	p.RawKeySize += uint64(len(e.Key))
+	p.RawValueSize += uint64(len(e.Value))
+	p.RawDataSize += e.Size()
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface. The
+// properties are encoded as entries sorted by the name.
+func (p *Properties) MarshalBinary() ([]byte, error) {
+	u64 := func(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
+
+	props := map[string][]byte{
+		propNumEntries:    u64(p.NumEntries),
+		propNumBlocks:     u64(p.NumBlocks),
+		propRawKeySize:    u64(p.RawKeySize),
+		propRawValueSize:  u64(p.RawValueSize),
+		propRawDataSize:   u64(p.RawDataSize),
+		propDataSize:      u64(p.DataSize),
+		propBlockSize:     u64(p.BlockSize),
+		propMaxKeySize:    u64(p.MaxKeySize),
+		propMaxValueSize:  u64(p.MaxValueSize),
+		propDuplicates:    {byte(p.Duplicates)},
+		propFormatVersion: binary.BigEndian.AppendUint32(nil, p.FormatVersion),
+		propCreationTime:  u64(uint64(p.CreationTime.UnixNano())), //nolint:gosec // round trips through int64
+	}
+
+	if p.SmallestKey != nil {
+		props[propSmallestKey] = p.SmallestKey
+		props[propLargestKey] = p.LargestKey
+	}
+
+	if p.RestartInterval != 0 {
+		props[propRestartInterval] = u64(p.RestartInterval)
+	}
+
+	if p.FilterBitsPerKey != 0 {
+		props[propFilterBitsPerKey] = u64(p.FilterBitsPerKey)
+	}
+
+	if p.Comparer != "" {
+		props[propComparer] = []byte(p.Comparer)
+	}
+
+	for name, value := range p.User {
+		props[name] = []byte(value)
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	buf := bytes.NewBuffer([]byte{})
+	for _, name := range names {
+		e := Entry{Key: []byte(name), Value: props[name]}
+		if _, err := e.WriteTo(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+// It ignores the collected properties that it doesn't know.
+func (p *Properties) UnmarshalBinary(data []byte) error {
+	*p = Properties{}
+
+	b := block(data)
+	for offset := 0; offset < len(b); {
+		e, next, err := b.entryAt(offset)
+		if err != nil {
+			return fmt.Errorf("Properties.UnmarshalBinary: %w", err)
+		}
+
+		offset = next
+		name := string(e.Key)
+
+		if !strings.HasPrefix(name, reservedPropertyPrefix) {
+			if p.User == nil {
+				p.User = map[string]string{}
+			}
+
+			p.User[name] = string(e.Value)
+
+			continue
+		}
+
+		if err := p.set(name, e.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// set sets the collected property of the name.
+func (p *Properties) set(name string, value []byte) error {
+	switch name {
+	case propSmallestKey:
+		p.SmallestKey = append([]byte{}, value...)
+	case propLargestKey:
+		p.LargestKey = append([]byte{}, value...)
+	case propDuplicates:
+		if len(value) != 1 {
+			return fmt.Errorf("Properties.UnmarshalBinary: invalid %s: %w", name, ErrCorrupt)
+		}
+
+		p.Duplicates = DuplicatePolicy(value[0])
+	case propComparer:
+		p.Comparer = string(value)
+	case propFormatVersion:
+		if len(value) != 4 {
+			return fmt.Errorf("Properties.UnmarshalBinary: invalid %s: %w", name, ErrCorrupt)
+		}
+
+		p.FormatVersion = binary.BigEndian.Uint32(value)
+	case propCreationTime:
+		v, err := propertyUint64(name, value)
+		if err != nil {
+			return err
+		}
+
+		p.CreationTime = time.Unix(0, int64(v)) //nolint:gosec // round trips through int64
+	default:
+		field := map[string]*uint64{
+			propNumEntries:       &p.NumEntries,
+			propNumBlocks:        &p.NumBlocks,
+			propRawKeySize:       &p.RawKeySize,
+			propRawValueSize:     &p.RawValueSize,
+			propRawDataSize:      &p.RawDataSize,
+			propDataSize:         &p.DataSize,
+			propBlockSize:        &p.BlockSize,
+			propMaxKeySize:       &p.MaxKeySize,
+			propMaxValueSize:     &p.MaxValueSize,
+			propRestartInterval:  &p.RestartInterval,
+			propFilterBitsPerKey: &p.FilterBitsPerKey,
+		}[name]
+		if field == nil {
+			return nil
+		}
+
+		v, err := propertyUint64(name, value)
+		if err != nil {
+			return err
+		}
+
+		*field = v
+	}
+
+	return nil
+}
+
+// propertyUint64 decodes the value of the property as uint64.
+func propertyUint64(name string, value []byte) (uint64, error) {
+	if len(value) != 8 {
+		return 0, fmt.Errorf("Properties.UnmarshalBinary: invalid %s: %w", name, ErrCorrupt)
+	}
+
+	return binary.BigEndian.Uint64(value), nil
+}
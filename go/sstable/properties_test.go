@@ -0,0 +1,117 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func ExampleSSTable_Properties() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithCompression(SnappyCompression))
+	for _, entry := range exampleFruits {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	if err := w.SetProperty("job", "fruits"); err != nil {
+		fmt.Println(err)
+	}
+
+	fmt.Println(w.SetProperty("sstable.num_entries", "0"))
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	s, err := NewSSTable(bytes.NewReader(b))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	p := s.Properties()
+	fmt.Println(p.NumEntries, p.NumBlocks, p.FormatVersion)
+	fmt.Printf("%s %s\n", p.SmallestKey, p.LargestKey)
+	fmt.Println(p.RawKeySize, p.RawValueSize, p.RawDataSize)
+	fmt.Println(p.DataSize > 0, !p.CreationTime.IsZero())
+	fmt.Println(p.User["job"])
+	// Output:
+	// Writer.SetProperty: reserved property name "sstable.num_entries"
+	// 4 1 4
+	// apple cherry
+	// 24 18 74
+	// true true
+	// fruits
+}
+
+func ExampleProperties_MarshalBinary() {
+	p := &Properties{NumEntries: 2, SmallestKey: []byte("a"), User: map[string]string{"owner": "me"}}
+
+	b, err := p.MarshalBinary()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var q Properties
+	if err := q.UnmarshalBinary(b); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Printf("%d %s %s\n", q.NumEntries, q.SmallestKey, q.User["owner"])
+	// Output:
+	// 2 a me
+}
+
+func ExampleProperties_defaults() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithCompression(SnappyCompression), WithMaxKeySize(100))
+	_ = w.Write(Entry{Key: []byte("apple"), Value: []byte("red")})
+	_ = w.Write(Entry{Key: []byte("apple"), Value: []byte("green")})
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// set overwrites the value of the reserved property.
+	set := func(property string, value ...byte) {
+		i := bytes.Index(b, []byte(reservedPropertyPrefix+property)) + len(reservedPropertyPrefix+property)
+		copy(b[i:], value)
+	}
+
+	// The table claims a limit that its keys exceed.
+	set("max_key_size", 0, 0, 0, 0, 0, 0, 0, 3)
+
+	_, err := NewSSTable(bytes.NewReader(b))
+	fmt.Println(err)
+
+	s, err := NewSSTable(bytes.NewReader(b), WithReadLimits(ReadLimits{MaxKeySize: 10}))
+	fmt.Println(s.Properties().MaxKeySize, err)
+
+	// The table claims its keys are unique.
+	set("max_key_size", 0, 0, 0, 0, 0, 0, 0, 100)
+	set("duplicates", byte(KeepFirst))
+
+	s, _ = NewSSTable(bytes.NewReader(b))
+	values, err := s.GetAll([]byte("apple"))
+	fmt.Printf("%s %v\n", values, err)
+
+	problems, _ := Verify(bytes.NewReader(b), VerifyOptions{})
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	// Output:
+	// sstable: corrupt table: key of 5 bytes exceeds the limit of 3
+	// 3 <nil>
+	// [red] <nil>
+	// duplicate in block 0 at offset 16: the key "apple" is repeated
+}
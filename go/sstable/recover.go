@@ -0,0 +1,470 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RecoverOptions configures Recover.
+type RecoverOptions struct {
+	// Resync makes Recover skip the damaged regions and look for the
+	// next record that decodes, instead of stopping at the first
+	// damaged record. It tries to decode a record at every byte offset
+	// of the damage, and skips the records whose lengths exceed the
+	// Limits, or 16 MiB where the Limits don't bound them.
+	Resync bool
+	// Version is the format version of the damaged table if its header
+	// is lost too. Zero means the version in the header, or format
+	// version 2 if the header has no known version.
+	Version uint32
+	// Limits bounds the lengths in the damaged table.
+	Limits ReadLimits
+}
+
+// Damage is a region of a table that Recover couldn't read.
+type Damage struct {
+	// Offset is the offset of the region in the file.
+	Offset uint64
+	// Length is the number of bytes of the region.
+	Length uint64
+	// Reason describes why the region couldn't be read.
+	Reason string
+}
+
+// RecoveryReport describes what Recover recovered and what was lost.
+type RecoveryReport struct {
+	// Version is the format version that Recover read the table as.
+	Version uint32
+	// Entries is the number of entries that Recover wrote.
+	Entries uint64
+	// Damage lists the regions that were lost in the order of the
+	// file.
+	Damage []Damage
+}
+
+// Recover salvages the entries of a damaged table in r, such as a table
+// whose Writer crashed before Close wrote the header and the index, and
+// writes them to w, which it closes even if it fails. It scans forward from the header
+// and decodes the entries until the first record that doesn't decode,
+// or, with Resync, skips the damaged regions. Entries whose keys are out
+// of order are damaged too. It returns an error only if r doesn't
+// report its size or w fails.
+func Recover(r io.ReaderAt, w *Writer, opts RecoverOptions) (*RecoveryReport, error) {
+	size, ok := sizeOf(r)
+	if !ok {
+		return nil, fmt.Errorf("Recover: the reader has no size: %w", ErrNotRandomAccess)
+	}
+
+	rec := &recoverer{r: r, w: w, opts: opts}
+	rec.readHeader(size)
+
+	if size < headerSize {
+		rec.report.Damage = append(rec.report.Damage, Damage{Length: size, Reason: "no header"})
+	}
+
+	for offset := uint64(headerSize); offset < rec.end; {
+		// A value that is stored out of line precedes its block, so the
+		// damage of a block starts after the values.
+		if offset = skipBlobs(r, rec.report.Version, offset, rec.end); offset >= rec.end {
+			break
+		}
+
+		n, err := rec.record(offset)
+		if err == errEndOfBlocks {
+			break
+		}
+
+		var werr *writeError
+		if errors.As(err, &werr) {
+			// The Writer keeps the first error, so Close only marks it
+			// closed.
+			_ = w.Close()
+
+			return &rec.report, werr.err
+		}
+
+		if err == nil {
+			offset += n
+			continue
+		}
+
+		next := rec.end
+		if opts.Resync {
+			next = rec.resync(offset + 1)
+		}
+
+		rec.report.Damage = append(rec.report.Damage, Damage{Offset: offset, Length: next - offset, Reason: err.Error()})
+		offset = next
+	}
+
+	if err := w.Close(); err != nil {
+		return &rec.report, err
+	}
+
+	return &rec.report, nil
+}
+
+// writeError is an error of the Writer, which ends Recover, unlike the
+// errors of the damaged table.
+type writeError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *writeError) Error() string {
+	return e.err.Error()
+}
+
+// recoverer holds the state of Recover.
+type recoverer struct {
+	r       io.ReaderAt
+	w       *Writer
+	opts    RecoverOptions
+	format  blockFormat
+	end     uint64
+	lastKey []byte
+	report  RecoveryReport
+}
+
+// readHeader finds the format version and the end of the data blocks of
+// the table of size bytes. The data blocks of a complete table end at
+// the index, and those of a damaged table at the end of the file.
+func (rec *recoverer) readHeader(size uint64) {
+	rec.end = size
+
+	var h header
+
+	data := make([]byte, headerSize)
+	if n, _ := rec.r.ReadAt(data, 0); n == len(data) {
+		_ = h.UnmarshalBinary(data)
+	}
+
+	switch {
+	case rec.opts.Version != 0:
+		h.version = rec.opts.Version
+	case h.checkVersion() != nil:
+		h = header{version: versionBase}
+	}
+
+	rec.report.Version = h.version
+	rec.format = blockFormatOf(h.version)
+
+	if h.version >= versionFooter {
+		footer := h
+		data := make([]byte, h.footerSize())
+
+		offset := size - min(size, uint64(len(data)))
+		if n, _ := rec.r.ReadAt(data, int64(offset)); n != len(data) || footer.unmarshalFooter(data, offset) != nil { //nolint:gosec // bounded by the size
+			return
+		}
+
+		h = footer
+	}
+
+	if h.indexOffset >= headerSize && h.indexOffset <= size {
+		rec.end = h.indexOffset
+	}
+}
+
+// record decodes the record at offset, which is an entry or a block
+// depending on the format, and writes its entries. It returns the
+// number of bytes of the record.
+func (rec *recoverer) record(offset uint64) (uint64, error) {
+	entries, refs, n, err := rec.decode(offset, rec.opts.Limits)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, e := range entries {
+		if refs[i] != nil {
+			if err := rec.writeBlob(e.Key, refs[i]); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		if err := rec.w.Write(*e); err != nil {
+			return 0, &writeError{err: err}
+		}
+
+		rec.lastKey = append(rec.lastKey[:0], e.Key...)
+		rec.report.Entries++
+	}
+
+	return n, nil
+}
+
+// decode decodes the record at offset within the limits and checks the
+// order of its keys. For values that are stored out of line, it returns
+// the blobRefs at the same positions as the entries.
+func (rec *recoverer) decode(offset uint64, limits ReadLimits) ([]*Entry, []*blobRef, uint64, error) {
+	if offset > rec.end {
+		return nil, nil, 0, io.ErrUnexpectedEOF
+	}
+
+	var (
+		entries []*Entry
+		refs    []*blobRef
+		n       uint64
+	)
+
+	if err := rec.checkHeader(offset, limits); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if !rec.format.framed {
+		data := io.NewSectionReader(rec.r, 0, int64(rec.end)) //nolint:gosec // bounded by the size
+
+		e, err := readEntryAt(data, offset, limits)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		entries, refs, n = []*Entry{e}, []*blobRef{nil}, e.Size()
+	} else {
+		sr := io.NewSectionReader(rec.r, int64(offset), int64(rec.end-offset)) //nolint:gosec // bounded by the size
+
+		b, m, err := rec.format.readBlockFrom(sr, offset, true, limits)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		if entries, refs, err = rec.blockEntries(b, limits); err != nil {
+			return nil, nil, 0, err
+		}
+
+		n = m
+	}
+
+	last := rec.lastKey
+	for _, e := range entries {
+		if last != nil && rec.w.Comparer().Compare(last, e.Key) > 0 {
+			return nil, nil, 0, fmt.Errorf("Recover: the key %q is after %q", e.Key, last)
+		}
+
+		last = e.Key
+	}
+
+	return entries, refs, n, nil
+}
+
+// errUnknownCodec is returned when a block header has a codec that
+// isn't registered. It is not formatted with the offset, since resync
+// may find many of them.
+var errUnknownCodec = fmt.Errorf("Recover: unknown codec: %w", ErrCorrupt)
+
+// checkHeader checks the header of the entry or the block at offset
+// before the record is read, so that resync doesn't read a record of a
+// garbage length at every offset. The record, or the value of a blob
+// frame, must fit in the data and the limits, and the codec of a block
+// must be known. The lengths are checked against the data first, since
+// that fails without formatting an error.
+func (rec *recoverer) checkHeader(offset uint64, limits ReadLimits) error {
+	var h [blobFrameSize]byte
+
+	n, _ := rec.r.ReadAt(h[:min(uint64(len(h)), rec.end-offset)], int64(offset)) //nolint:gosec // bounded by the size
+
+	if !rec.format.framed {
+		if n < 8 {
+			return io.ErrUnexpectedEOF
+		}
+
+		keyLength := uint64(binary.BigEndian.Uint32(h[:4]))
+		valueLength := uint64(binary.BigEndian.Uint32(h[4:8]))
+
+		if keyLength+valueLength > rec.end-offset-8 {
+			return io.ErrUnexpectedEOF
+		}
+
+		return limits.checkEntry(keyLength, valueLength)
+	}
+
+	if n < blockHeaderSize {
+		return io.ErrUnexpectedEOF
+	}
+
+	rest := rec.end - offset - blockHeaderSize
+	trailer := uint64(rec.format.trailerSize())
+
+	switch id := CodecID(h[4]); {
+	case rec.format.terminated && id == endOfBlocks:
+		return nil
+	case rec.format.terminated && id == blobFrame:
+		if n < blobFrameSize || binary.BigEndian.Uint64(h[blockHeaderSize:]) > rest-min(rest, 8+trailer) {
+			return io.ErrUnexpectedEOF
+		}
+
+		return limits.checkEntry(0, binary.BigEndian.Uint64(h[blockHeaderSize:]))
+	default:
+		if !isCodec(id) {
+			return errUnknownCodec
+		}
+
+		length := uint64(binary.BigEndian.Uint32(h[:4])) + trailer
+		if length > rest {
+			return io.ErrUnexpectedEOF
+		}
+
+		return limits.checkBlock(blockHeaderSize + length)
+	}
+}
+
+// blockEntries returns the entries of the raw block within the limits
+// and the blobRefs of the values that are stored out of line.
+func (rec *recoverer) blockEntries(b block, limits ReadLimits) ([]*Entry, []*blobRef, error) {
+	encoding, rest, err := rec.format.encoding(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if encoding == blobEncoding {
+		e, ref, err := unmarshalBlobBlock(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return []*Entry{e}, []*blobRef{ref}, nil
+	}
+
+	entries, err := rec.format.entries(b, nil, limits)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, nil, errors.New("Recover: empty block")
+	}
+
+	return entries, make([]*blobRef, len(entries)), nil
+}
+
+// writeBlob writes the entry of the key whose value ref locates. It
+// reads the value once and verifies its checksum while copying it, and
+// adds the entry only if the checksum matches, so that a damaged value
+// doesn't break w.
+func (rec *recoverer) writeBlob(key []byte, ref *blobRef) error {
+	if ref.length > rec.end-min(rec.end, ref.offset) {
+		return fmt.Errorf("Recover: the value at offset %d ends beyond the data", ref.offset)
+	}
+
+	if err := rec.opts.Limits.checkEntry(0, ref.length); err != nil {
+		return err
+	}
+
+	br, err := newBlobReader(rec.r, ref, false)
+	if err != nil {
+		return err
+	}
+
+	if rec.w.version < versionLargeValues {
+		value, err := readFull(br, ref.length)
+		if err != nil {
+			return err
+		}
+
+		if err := rec.verifyValue(ref, checksum(value)); err != nil {
+			return err
+		}
+
+		if err := rec.w.Write(Entry{Key: key, Value: value}); err != nil {
+			return &writeError{err: err}
+		}
+	} else if err := rec.copyValue(key, br, ref); err != nil {
+		return err
+	}
+
+	rec.lastKey = append(rec.lastKey[:0], key...)
+	rec.report.Entries++
+
+	return nil
+}
+
+// copyValue copies the value that ref locates from r into a blob frame
+// of w, and adds the entry of the key once the checksum of the copied
+// value matches.
+func (rec *recoverer) copyValue(key []byte, r io.Reader, ref *blobRef) error {
+	ok, err := rec.w.admitValue(key, int64(ref.length)) //nolint:gosec // checked by newBlobReader
+	if err != nil {
+		return &writeError{err: err}
+	}
+
+	if !ok {
+		return nil
+	}
+
+	cr := &checksumReader{r: r}
+
+	copied, err := rec.w.copyValue(cr, int64(ref.length)) //nolint:gosec // checked by newBlobReader
+	if err != nil {
+		return &writeError{err: err}
+	}
+
+	if err := rec.verifyValue(ref, cr.crc); err != nil {
+		return err
+	}
+
+	if err := rec.w.add(Entry{Key: key}, copied); err != nil {
+		return &writeError{err: err}
+	}
+
+	return nil
+}
+
+// verifyValue returns a *CorruptionError if the checksum of the value
+// that ref locates isn't crc. Values of tables without checksums always
+// pass.
+func (rec *recoverer) verifyValue(ref *blobRef, crc uint32) error {
+	if !rec.format.checksummed {
+		return nil
+	}
+
+	var trailer [checksumSize]byte
+
+	end := ref.offset + ref.length
+	if end > rec.end-min(rec.end, checksumSize) {
+		return io.ErrUnexpectedEOF
+	}
+
+	if n, err := rec.r.ReadAt(trailer[:], int64(end)); n != len(trailer) { //nolint:gosec // bounded by the end
+		return err
+	}
+
+	if binary.BigEndian.Uint32(trailer[:]) != crc {
+		return &CorruptionError{Offset: ref.offset, What: "value"}
+	}
+
+	return nil
+}
+
+// resyncSize bounds the keys, values and blocks that resync reads at an
+// offset where the Limits don't bound them. A garbage length at every
+// offset of the damage would otherwise make resync read up to the rest
+// of the data at each of them.
+const resyncSize = 16 << 20
+
+// resync returns the offset of the next record at or after offset that
+// decodes, or the end of the data if there is none. It tries every byte
+// offset, reading at most resyncSize bytes at each.
+func (rec *recoverer) resync(offset uint64) uint64 {
+	limits := rec.opts.Limits
+	for _, l := range []*uint64{&limits.MaxKeySize, &limits.MaxValueSize, &limits.MaxBlockSize} {
+		if *l == 0 {
+			*l = resyncSize
+		}
+	}
+
+	for ; offset < rec.end; offset++ {
+		if _, _, _, err := rec.decode(offset, limits); err == nil {
+			return offset
+		}
+	}
+
+	return rec.end
+}
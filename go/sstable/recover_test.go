@@ -0,0 +1,175 @@
+package sstable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleRecover() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f)
+	for _, entry := range exampleFruits {
+		_ = w.Write(entry)
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// Lose the header and the index like a crashed Writer does, and
+	// break the key length of "apricot".
+	b = b[:binary.BigEndian.Uint64(b[8:])]
+	copy(b[4:], make([]byte, 12))
+	binary.BigEndian.PutUint32(b[bytes.Index(b, []byte("apricot"))-8:], 0xffffffff)
+
+	for _, resync := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		report, err := Recover(bytes.NewReader(b), NewWriter(&buf), RecoverOptions{Resync: resync})
+		fmt.Println(report.Entries, report.Damage, err)
+
+		var keys []string
+
+		s, _ := NewSSTable(bytes.NewReader(buf.Bytes()))
+		for c := s.ScanFrom(nil); !c.Done(); c.Next() {
+			keys = append(keys, string(c.Entry().Key))
+		}
+
+		fmt.Println(keys)
+	}
+	// Output:
+	// 1 [{32 58 unexpected EOF}] <nil>
+	// [apple]
+	// 3 [{32 21 unexpected EOF}] <nil>
+	// [apple banana cherry]
+}
+
+func ExampleRecover_largeValues() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithLargeValues(), WithChecksums(), WithBlockSize(16))
+	for _, entry := range exampleFruits[:2] {
+		_ = w.Write(entry)
+	}
+
+	value := strings.Repeat("green", 10)
+	_ = w.WriteValueFrom([]byte("kiwi"), strings.NewReader(value), int64(len(value)))
+	_ = w.Write(Entry{Key: []byte("lemon"), Value: []byte("yellow")})
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// Flip a bit of the value "orange" of "apricot".
+	b[bytes.Index(b, []byte("orange"))] ^= 0x20
+
+	recoverTo := func(b []byte) {
+		f, _ := os.CreateTemp("", "")
+
+		name := f.Name()
+		defer os.Remove(name)
+
+		report, err := Recover(bytes.NewReader(b), NewWriter(f, WithLargeValues(), WithChecksums()), RecoverOptions{Resync: true})
+		fmt.Println(report.Version, report.Entries, err)
+
+		for _, d := range report.Damage {
+			fmt.Println(d.Offset, d.Length, d.Reason)
+		}
+
+		recovered, _ := os.ReadFile(name)
+		problems, err := Verify(bytes.NewReader(recovered), VerifyOptions{})
+		fmt.Println(len(problems), err)
+
+		s, _ := NewSSTable(bytes.NewReader(recovered))
+		for _, key := range []string{"apple", "apricot", "kiwi", "lemon"} {
+			value, ok, _ := s.Get([]byte(key))
+			fmt.Println(key, ok, len(value))
+		}
+	}
+
+	recoverTo(b)
+
+	// A damaged large value is copied before its checksum fails, but
+	// its entry is left out.
+	b[bytes.Index(b, []byte("green"))] ^= 0x20
+	recoverTo(b)
+	// Output:
+	// 10 3 <nil>
+	// 109 31 sstable: checksum mismatch in data block at offset 109
+	// 0 <nil>
+	// apple true 3
+	// apricot false 0
+	// kiwi true 50
+	// lemon true 6
+	// 10 2 <nil>
+	// 109 31 sstable: checksum mismatch in data block at offset 109
+	// 140 34 sstable: checksum mismatch in value at offset 55
+	// 0 <nil>
+	// apple true 3
+	// apricot false 0
+	// kiwi false 0
+	// lemon true 6
+}
+
+// noisyTable returns a table of n entries written with the options
+// whose bytes from the offset noise bytes long are random.
+func noisyTable(n, offset, noise int, opts ...WriterOption) []byte {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, opts...)
+	for i := range n {
+		_ = w.Write(Entry{Key: fmt.Appendf(nil, "key%06d", i), Value: fmt.Appendf(nil, "value %033d", i)})
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range noise {
+		b[offset+i] = byte(rng.Uint32())
+	}
+
+	return b
+}
+
+func ExampleRecover_resync() {
+	// Resync looks for a block at each offset of the noise.
+	b := noisyTable(1000, 16<<10, 8<<10, WithChecksums(), WithBlockSize(1024))
+
+	report, err := Recover(bytes.NewReader(b), NewWriter(io.Discard), RecoverOptions{Resync: true})
+	fmt.Println(report.Entries, report.Damage, err)
+	// Output:
+	// 838 [{16288 9153 sstable: checksum mismatch in data block at offset 16288}] <nil>
+}
+
+func BenchmarkRecover_resync(b *testing.B) {
+	for _, version := range []uint32{versionBase, versionChecksums} {
+		b.Run(fmt.Sprint(version), func(b *testing.B) {
+			data := noisyTable(100000, 1<<20, 2<<20, WithFormatVersion(version))
+			b.SetBytes(int64(len(data)))
+
+			for b.Loop() {
+				if _, err := Recover(bytes.NewReader(data), NewWriter(io.Discard), RecoverOptions{Resync: true}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package sstable
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// This file implements the Snappy block format as described in
+// https://github.com/google/snappy/blob/main/format_description.txt,
+// so that SSTables can be compressed without external dependencies.
+
+// Snappy element tags in the low 2 bits of the tag byte.
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+	snappyTagCopy4   = 0x03
+)
+
+// snappyTableBits is the log2 of the size of the hash table of the
+// encoder.
+const snappyTableBits = 14
+
+// errSnappyCorrupt is returned when the input is not valid Snappy
+// data.
+var errSnappyCorrupt = fmt.Errorf("snappy: corrupt input: %w", ErrCorrupt)
+
+// snappyHash hashes 4 bytes into an index of the hash table.
+func snappyHash(u uint32) uint32 {
+	return (u * 0x1e35a7bd) >> (32 - snappyTableBits)
+}
+
+// snappyEncode returns the Snappy encoding of src.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(nil, uint64(len(src)))
+	if len(src) < 4 {
+		return snappyAppendLiteral(dst, src)
+	}
+
+	// table holds the position plus one of the last occurrence of the
+	// hash so that zero means no occurrence.
+	var table [1 << snappyTableBits]int
+
+	literal := 0
+
+	for i := 0; i+4 <= len(src); {
+		u := binary.LittleEndian.Uint32(src[i:])
+		h := snappyHash(u)
+		candidate := table[h] - 1
+		table[h] = i + 1
+
+		if candidate < 0 || i-candidate > 0xffff || binary.LittleEndian.Uint32(src[candidate:]) != u {
+			i++
+			continue
+		}
+
+		dst = snappyAppendLiteral(dst, src[literal:i])
+
+		length := 4
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+
+		dst = snappyAppendCopy(dst, i-candidate, length)
+		i += length
+		literal = i
+	}
+
+	return snappyAppendLiteral(dst, src[literal:])
+}
+
+// snappyAppendLiteral appends a literal element of lit to dst.
+func snappyAppendLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+
+	n := uint32(len(lit) - 1) //nolint:gosec // blocks are far smaller than 4 GiB
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyTagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+
+	return append(dst, lit...)
+}
+
+// snappyAppendCopy appends copy elements of length bytes from offset
+// bytes back to dst. offset is at most 0xffff and length is at least 4.
+func snappyAppendCopy(dst []byte, offset, length int) []byte {
+	for length >= 68 {
+		dst = append(dst, 63<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+
+	if length > 64 {
+		dst = append(dst, 59<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+
+	if length >= 12 || offset >= 2048 {
+		return append(dst, byte(length-1)<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+	}
+
+	return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|snappyTagCopy1, byte(offset))
+}
+
+// snappyDecode returns the data that src encodes.
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > uint64(len(src))*255 {
+		return nil, errSnappyCorrupt
+	}
+
+	dst := make([]byte, 0, length)
+	src = src[n:]
+
+	for len(src) > 0 {
+		tag := src[0]
+
+		var offset, count int
+
+		switch tag & 0x03 {
+		case snappyTagLiteral:
+			count = int(tag >> 2)
+			src = src[1:]
+
+			if count >= 60 {
+				extra := count - 59
+				if len(src) < extra {
+					return nil, errSnappyCorrupt
+				}
+
+				count = 0
+				for i := extra - 1; i >= 0; i-- {
+					count = count<<8 | int(src[i])
+				}
+
+				src = src[extra:]
+			}
+
+			count++
+			if len(src) < count || uint64(len(dst)+count) > length {
+				return nil, errSnappyCorrupt
+			}
+
+			dst = append(dst, src[:count]...)
+			src = src[count:]
+
+			continue
+		case snappyTagCopy1:
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+
+			count = int(tag>>2&0x07) + 4
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case snappyTagCopy2:
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+
+			count = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src[1:3]))
+			src = src[3:]
+		case snappyTagCopy4:
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+
+			count = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(src[1:5]))
+			src = src[5:]
+		}
+
+		if offset <= 0 || offset > len(dst) || uint64(len(dst)+count) > length {
+			return nil, errSnappyCorrupt
+		}
+
+		// The source and the destination may overlap, so copy byte by
+		// byte.
+		start := len(dst) - offset
+		for i := 0; i < count; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+
+	if uint64(len(dst)) != length {
+		return nil, errSnappyCorrupt
+	}
+
+	return dst, nil
+}
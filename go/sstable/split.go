@@ -0,0 +1,204 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// SplitPoints returns up to n-1 keys that split the SSTable into n key
+// ranges of roughly equal sizes in the file, so that n workers can each
+// scan one range, from a split point inclusive to the next one
+// exclusive. The keys are the first keys of data blocks, in increasing
+// order, so there are fewer of them if the SSTable has too few blocks.
+// It only reads the index, and the partitions of a partitioned index,
+// which requires the reader to be an io.ReaderAt.
+func (s *SSTable) SplitPoints(n int) ([][]byte, error) {
+	if n <= 1 {
+		return nil, nil
+	}
+
+	blocks, err := s.blockIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	start := blockStart(blocks, 0)
+	size := dataEnd(blocks) - start
+
+	var points [][]byte
+
+	i := 1
+	for k := 1; k < n && i < len(blocks); k++ {
+		target := start + size/uint64(n)*uint64(k) + size%uint64(n)*uint64(k)/uint64(n)
+
+		// Pick the block whose start is the closest to the target.
+		for i < len(blocks)-1 && blockStart(blocks, i+1) <= target {
+			i++
+		}
+
+		if i < len(blocks)-1 && blockStart(blocks, i+1)-target < target-min(target, blockStart(blocks, i)) {
+			i++
+		}
+
+		key := blocks[i].keyBytes
+		if s.cmp.Compare(key, blocks[0].keyBytes) <= 0 || len(points) > 0 && s.cmp.Compare(key, points[len(points)-1]) <= 0 {
+			continue
+		}
+
+		points = append(points, bytes.Clone(key))
+		i++
+	}
+
+	return points, nil
+}
+
+// ApproximateOffsetOf returns the approximate offset in the file where
+// the data of the key would be, which is the start of the data block
+// that might contain the key. It returns the start of the data for the
+// keys before the first key, and the end of the data for the keys after
+// the largest key in the properties. It only reads the index, and a
+// partition of a partitioned index, which requires the reader to be an
+// io.ReaderAt.
+func (s *SSTable) ApproximateOffsetOf(key []byte) (uint64, error) {
+	if s.numBlocks() == 0 {
+		return headerSize, nil
+	}
+
+	if s.props != nil && s.props.LargestKey != nil && s.cmp.Compare(key, s.props.LargestKey) > 0 {
+		blocks, err := s.lastBlocks()
+		if err != nil {
+			return 0, err
+		}
+
+		return dataEnd(blocks), nil
+	}
+
+	var r io.ReaderAt
+	if s.partitions != nil {
+		var ok bool
+		if r, ok = s.reader.(io.ReaderAt); !ok {
+			return 0, fmt.Errorf("SSTable.ApproximateOffsetOf: %w", ErrNotRandomAccess)
+		}
+	}
+
+	i, err := s.blockIndexOf(r, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if i <= 0 {
+		return headerSize, nil
+	}
+
+	// The block starts where the previous one ends, which includes the
+	// values stored out of line before it.
+	e, err := s.blockEntry(r, i-1)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.blockOffset + uint64(e.blockLength), nil
+}
+
+// ApproximateSize returns the approximate number of bytes in the file
+// of the data of the keys between start inclusive and end exclusive. A
+// nil start means the beginning and a nil end means the end of the
+// SSTable. It only reads what ApproximateOffsetOf reads.
+func (s *SSTable) ApproximateSize(start, end []byte) (uint64, error) {
+	from := uint64(headerSize)
+
+	if start != nil {
+		var err error
+		if from, err = s.ApproximateOffsetOf(start); err != nil {
+			return 0, err
+		}
+	}
+
+	var to uint64
+
+	if end == nil {
+		blocks, err := s.lastBlocks()
+		if err != nil {
+			return 0, err
+		}
+
+		to = dataEnd(blocks)
+	} else {
+		var err error
+		if to, err = s.ApproximateOffsetOf(end); err != nil {
+			return 0, err
+		}
+	}
+
+	return to - min(to, from), nil
+}
+
+// blockIndex returns the index entries of all the data blocks. It reads
+// all the partitions of a partitioned index.
+func (s *SSTable) blockIndex() (index, error) {
+	if s.partitions == nil {
+		return s.index, nil
+	}
+
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, fmt.Errorf("SSTable.blockIndex: %w", ErrNotRandomAccess)
+	}
+
+	blocks := make(index, 0, s.numBlocks())
+
+	for p := range s.index {
+		partition, err := s.readPartition(r, p)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks = append(blocks, partition...)
+	}
+
+	return blocks, nil
+}
+
+// lastBlocks returns the index entries of the last partition of a
+// partitioned index, or the whole index otherwise, whose last entry is
+// the last data block.
+func (s *SSTable) lastBlocks() (index, error) {
+	if s.partitions == nil || len(s.index) == 0 {
+		return s.index, nil
+	}
+
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, fmt.Errorf("SSTable.lastBlocks: %w", ErrNotRandomAccess)
+	}
+
+	return s.readPartition(r, len(s.index)-1)
+}
+
+// dataEnd returns the end of the last data block in blocks, or the
+// start of the data if there are none.
+func dataEnd(blocks index) uint64 {
+	if len(blocks) == 0 {
+		return headerSize
+	}
+
+	last := blocks[len(blocks)-1]
+
+	return last.blockOffset + uint64(last.blockLength)
+}
+
+// blockStart returns where the i-th block in blocks starts, which is
+// where the previous block ends, so that the values stored out of line
+// before a block count for it.
+func blockStart(blocks index, i int) uint64 {
+	if i == 0 {
+		return headerSize
+	}
+
+	return blocks[i-1].blockOffset + uint64(blocks[i-1].blockLength)
+}
@@ -0,0 +1,50 @@
+package sstable
+
+import (
+	"fmt"
+	"os"
+)
+
+func ExampleSSTable_SplitPoints() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithBlockSize(64))
+	for i := range 100 {
+		_ = w.Write(Entry{Key: fmt.Appendf(nil, "key%03d", i), Value: []byte("value")})
+	}
+
+	w.Close()
+
+	f, _ = os.Open(name)
+	defer f.Close()
+
+	s, _ := NewSSTable(f)
+
+	points, err := s.SplitPoints(4)
+	fmt.Printf("%q %v\n", points, err)
+
+	start := []byte(nil)
+	for _, end := range append(points, nil) {
+		size, _ := s.ApproximateSize(start, end)
+		fmt.Println(size)
+
+		start = end
+	}
+
+	for _, key := range []string{"", "key050", "key999"} {
+		offset, _ := s.ApproximateOffsetOf([]byte(key))
+		fmt.Println(key, offset)
+	}
+	// Output:
+	// ["key024" "key048" "key076"] <nil>
+	// 456
+	// 456
+	// 532
+	// 456
+	//  16
+	// key050 928
+	// key999 1840
+}
@@ -1,28 +1,70 @@
 package sstable
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"math"
+	"sync/atomic"
 )
 
 // SSTable implements read only random access of the SSTable.
 type SSTable struct {
-	header   header
-	index    index
-	reader   interface{}
-	noCursor bool
+	header     header
+	index      index
+	partitions *partitions
+	partition  atomic.Pointer[decodedPartition]
+	filter     bloomFilter
+	props      *Properties
+	cmp        Comparer
+	cache      *BlockCache
+	limits     ReadLimits
+	id         uint64
+	reader     interface{}
+	closer     io.Closer
+	noCursor   bool
+	lenient    bool
+}
+
+// ReaderOption configures a SSTable.
+type ReaderOption func(*SSTable)
+
+// WithBlockCache makes the SSTable keep the data blocks that it reads
+// in the cache. The cache can be shared with other SSTables. It only
+// applies to random access readers that copy the blocks, so tables
+// opened with OpenMmap don't use it. A nil cache means no cache.
+func WithBlockCache(c *BlockCache) ReaderOption {
+	return func(s *SSTable) {
+		s.cache = c
+		if c != nil {
+			s.id = c.newTableID()
+		}
+	}
+}
+
+// ExpectComparer makes NewSSTable fail unless the table is ordered by
+// c. Without it, the SSTable uses the registered comparer of the name
+// that the table records.
+func ExpectComparer(c Comparer) ReaderOption {
+	return func(s *SSTable) {
+		s.cmp = c
+	}
 }
 
 // NewSSTable creates a SSTable struct.
-func NewSSTable(r interface{}) (*SSTable, error) {
+func NewSSTable(r interface{}, opts ...ReaderOption) (*SSTable, error) {
 	table := SSTable{
 		header: header{},
 		index:  index{},
 		reader: r,
 	}
 
+	for _, opt := range opts {
+		opt(&table)
+	}
+
 	switch r := r.(type) {
 	case io.ReadSeeker:
 		newOffset, err := r.Seek(0, 0)
@@ -38,8 +80,22 @@ func NewSSTable(r interface{}) (*SSTable, error) {
 			return nil, err
 		}
 
+		if table.header.version >= versionFooter {
+			if err := table.readFooterFrom(r); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := table.header.checkIndexOffset(); err != nil {
+			return nil, err
+		}
+
+		if err := table.checkLayout(); err != nil {
+			return nil, err
+		}
+
 		if table.header.indexOffset > math.MaxInt64 {
-			panic("unimplemented")
+			return nil, fmt.Errorf("NewSSTable: index %w: %d", ErrOffsetOverflow, table.header.indexOffset)
 		}
 
 		newOffset, err = r.Seek(int64(table.header.indexOffset), 0) //nolint:gosec // overflow checked above
@@ -48,10 +104,22 @@ func NewSSTable(r interface{}) (*SSTable, error) {
 		}
 
 		if newOffset < 0 || uint64(newOffset) != table.header.indexOffset { //nolint:gosec // newOffset checked non-negative
-			return nil, errors.New("NewSSTable: new offset is not same as the index offset")
+			return nil, fmt.Errorf("%w: index offset %d is beyond the end", ErrCorrupt, table.header.indexOffset)
+		}
+
+		if table.header.version >= versionMetaBlocks {
+			if err := table.readIndexAndMeta(r); err != nil {
+				return nil, err
+			}
+		} else if _, err := table.index.ReadFrom(r); err != nil {
+			return nil, err
+		}
+
+		if err := table.checkIndex(); err != nil && !table.lenient {
+			return nil, err
 		}
 
-		if _, err := table.index.ReadFrom(r); err != nil {
+		if err := table.resolveComparer(); err != nil {
 			return nil, err
 		}
 	case io.ReaderAt:
@@ -64,73 +132,612 @@ func NewSSTable(r interface{}) (*SSTable, error) {
 			return nil, err
 		}
 
-		if err := table.index.ReadAt(r, table.header.indexOffset); err != nil {
+		if err := table.header.checkVersion(); err != nil {
+			return nil, err
+		}
+
+		if table.header.version >= versionFooter {
+			if err := table.readFooterAt(r); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := table.header.checkIndexOffset(); err != nil {
+			return nil, err
+		}
+
+		if err := table.checkLayout(); err != nil {
+			return nil, err
+		}
+
+		if table.header.version >= versionMetaBlocks {
+			if table.header.indexOffset > math.MaxInt64 {
+				return nil, fmt.Errorf("NewSSTable: index %w: %d", ErrOffsetOverflow, table.header.indexOffset)
+			}
+
+			offset := int64(table.header.indexOffset) //nolint:gosec // overflow checked above
+			if err := table.readIndexAndMeta(io.NewSectionReader(r, offset, math.MaxInt64-offset)); err != nil {
+				return nil, err
+			}
+		} else if err := table.index.ReadAt(r, table.header.indexOffset); err != nil {
+			return nil, err
+		}
+
+		if err := table.checkIndex(); err != nil && !table.lenient {
+			return nil, err
+		}
+
+		if err := table.resolveComparer(); err != nil {
 			return nil, err
 		}
 	case io.Reader:
-		// Index can't be read if the reader isn't random access.
+		// Index can't be read if the reader isn't random access, so
+		// neither can the comparer be checked.
 		if err := table.header.read(r); err != nil {
 			return nil, err
 		}
+
+		if table.cmp == nil {
+			table.cmp = BytewiseComparer
+		}
 	default:
-		panic("unimplemented")
+		return nil, fmt.Errorf("NewSSTable: %T is not a reader: %w", r, ErrNotRandomAccess)
 	}
 
 	return &table, nil
 }
 
+// resolveComparer checks the comparer of the SSTable against the one
+// that the table records, or looks it up if none is expected. It drops
+// the bloom filter unless the order is bytewise, because the filter
+// hashes the bytes of the keys and keys that compare equal in another
+// order may differ in bytes.
+func (s *SSTable) resolveComparer() error {
+	if err := s.checkComparer(); err != nil {
+		return err
+	}
+
+	if s.cmp.Name() != BytewiseComparer.Name() {
+		s.filter = nil
+	}
+
+	return nil
+}
+
+// checkComparer checks the comparer of the SSTable against the one
+// that the table records, or looks it up if none is expected.
+func (s *SSTable) checkComparer() error {
+	name := BytewiseComparer.Name()
+	if s.props != nil && s.props.Comparer != "" {
+		name = s.props.Comparer
+	}
+
+	if s.cmp == nil {
+		c, err := lookupComparer(name)
+		if err != nil {
+			return fmt.Errorf("failed to open the table: %w", err)
+		}
+
+		s.cmp = c
+
+		return nil
+	}
+
+	if s.cmp.Name() != name {
+		return fmt.Errorf("NewSSTable: table is ordered by comparer %q, not %q", name, s.cmp.Name())
+	}
+
+	return nil
+}
+
+// readFooterFrom reads the footer at the end of r.
+func (s *SSTable) readFooterFrom(r io.ReadSeeker) error {
+	buf := make([]byte, s.header.footerSize())
+
+	offset, err := r.Seek(-int64(len(buf)), io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to seek to the footer: %w", err)
+	}
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("failed to read the footer: %w", err)
+	}
+
+	return s.header.unmarshalFooter(buf, uint64(offset)) //nolint:gosec // Seek returns a non-negative offset on success
+}
+
+// readFooterAt reads the footer at the end of r. r must have a Size
+// method to find the end, like bytes.Reader and io.SectionReader.
+func (s *SSTable) readFooterAt(r io.ReaderAt) error {
+	sizer, ok := r.(interface{ Size() int64 })
+	if !ok {
+		return errors.New("NewSSTable: reader has no Size method to find the footer")
+	}
+
+	buf := make([]byte, s.header.footerSize())
+
+	offset := sizer.Size() - int64(len(buf))
+	if offset < headerSize {
+		return fmt.Errorf("failed to read the footer: %w", io.ErrUnexpectedEOF)
+	}
+
+	if n, err := r.ReadAt(buf, offset); n != len(buf) {
+		return fmt.Errorf("failed to read the footer: %w", err)
+	}
+
+	return s.header.unmarshalFooter(buf, uint64(offset)) //nolint:gosec // offset checked positive above
+}
+
+// checkLayout checks the index offset and the number of blocks in the
+// header against the size of the file, if the reader reports it, before
+// the index is read.
+func (s *SSTable) checkLayout() error {
+	size, ok := sizeOf(s.reader)
+	if !ok {
+		return nil
+	}
+
+	end := size
+	if s.header.version >= versionFooter {
+		end -= min(end, uint64(s.header.footerSize()))
+	}
+
+	if s.header.indexOffset > end {
+		return fmt.Errorf("%w: index offset %d is beyond the end of the file", ErrCorrupt, s.header.indexOffset)
+	}
+
+	// Every index entry takes at least 16 bytes.
+	if uint64(s.header.numBlocks) > (end-s.header.indexOffset)/16 {
+		return fmt.Errorf("%w: %d index entries don't fit in the file", ErrCorrupt, s.header.numBlocks)
+	}
+
+	return nil
+}
+
+// checkIndex checks the index against the header and the partitions.
+func (s *SSTable) checkIndex() error {
+	if uint64(len(s.index)) != uint64(s.header.numBlocks) {
+		return fmt.Errorf("%w: the index has %d entries, not %d", ErrCorrupt, len(s.index), s.header.numBlocks)
+	}
+
+	if p := s.partitions; p != nil {
+		n := p.numBlocks/uint64(p.blocksPerPartition) + min(p.numBlocks%uint64(p.blocksPerPartition), 1)
+		if n != uint64(len(s.index)) {
+			return fmt.Errorf("%w: %d blocks don't fit in %d partitions", ErrCorrupt, p.numBlocks, len(s.index))
+		}
+	}
+
+	return s.index.check(s.header.indexOffset, s.limits)
+}
+
+// readIndexAndMeta reads the index and the meta blocks that follow
+// it from r.
+func (s *SSTable) readIndexAndMeta(r io.Reader) error {
+	br := bufio.NewReader(r)
+	checksummed := s.header.version >= versionChecksums
+
+	cr := &checksumReader{r: br}
+	if err := s.index.readN(cr, s.header.numBlocks); err != nil {
+		return fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	if checksummed {
+		if ok, err := cr.verifyChecksum(); err != nil {
+			return fmt.Errorf("failed to read the index checksum: %w", err)
+		} else if !ok {
+			return &CorruptionError{Offset: s.header.indexOffset, What: "index"}
+		}
+	}
+
+	cr = &checksumReader{r: br}
+
+	var meta metaIndex
+	if _, err := meta.readFrom(cr, s.limits); err != nil {
+		return fmt.Errorf("failed to read the meta blocks: %w", err)
+	}
+
+	if checksummed {
+		if ok, err := cr.verifyChecksum(); err != nil {
+			return fmt.Errorf("failed to read the meta blocks checksum: %w", err)
+		} else if !ok {
+			return &CorruptionError{Offset: s.header.indexOffset, What: "meta blocks"}
+		}
+	}
+
+	if data := meta.find(filterMetaBlockName); data != nil {
+		filter, err := parseBloomFilter(data)
+		if err != nil {
+			return err
+		}
+
+		s.filter = filter
+	}
+
+	if data := meta.find(partitionsMetaBlockName); data != nil {
+		s.partitions = &partitions{}
+		if err := s.partitions.UnmarshalBinary(data); err != nil {
+			return err
+		}
+	}
+
+	if data := meta.find(propertiesMetaBlockName); data != nil {
+		s.props = &Properties{}
+		if err := s.props.UnmarshalBinary(data); err != nil {
+			return err
+		}
+
+		s.limits = s.limits.withDefaults(s.props)
+	}
+
+	return nil
+}
+
+// zeroCopy returns true if the entries of the SSTable point into the
+// memory of the reader instead of being copied.
+func (s *SSTable) zeroCopy() bool {
+	_, ok := s.reader.(blockSlicer)
+	return ok
+}
+
+// readBlock reads the i-th data block from r through the block cache
+// and decompresses it. It verifies the checksum of the block if verify
+// is true; unverified blocks are not added to the cache.
+func (s *SSTable) readBlock(r io.ReaderAt, i int, verify bool) (block, error) {
+	e, err := s.blockEntry(r, i)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.limits.checkBlock(uint64(e.blockLength)); err != nil {
+		return nil, err
+	}
+
+	format := blockFormatOf(s.header.version)
+
+	if sl, ok := r.(blockSlicer); ok {
+		b, err := sl.slice(e.blockOffset, uint64(e.blockLength))
+		if err != nil {
+			return nil, err
+		}
+
+		return format.decodeBlock(b, e.blockOffset, verify, s.limits)
+	}
+
+	var key blockCacheKey
+	if s.cache != nil {
+		key = blockCacheKey{table: s.id, offset: e.blockOffset}
+		if b, ok := s.cache.get(key); ok {
+			return b, nil
+		}
+	}
+
+	b, err := readBlock(r, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, err = format.decodeBlock(b, e.blockOffset, verify, s.limits); err != nil {
+		return nil, err
+	}
+
+	if s.cache != nil && (verify || !format.checksummed) {
+		s.cache.add(key, b)
+	}
+
+	return b, nil
+}
+
 // ScanFrom scans from the key to the end of the SSTable. If key is
 // nil, scan from the beginning.
 func (s *SSTable) ScanFrom(key []byte) Cursor {
-	switch s.reader.(type) {
+	return s.scanFrom(key, true)
+}
+
+// scanFrom scans from the key to the end of the SSTable and verifies
+// the checksums of the blocks if verify is true.
+func (s *SSTable) scanFrom(key []byte, verify bool) Cursor {
+	switch r := s.reader.(type) {
 	case io.ReaderAt:
+		it := &Iterator{table: s, reader: r, block: -1, skipChecksums: !verify}
 		if key == nil {
-			return &CursorToOffset{
-				reader:    s.reader,
+			// A nil key isn't the smallest in every order.
+			it.SeekToFirst()
+		} else {
+			it.Seek(key)
+		}
+
+		return it
+	case io.Reader:
+		if s.noCursor {
+			return errCursor{err: fmt.Errorf("SSTable.ScanFrom: %w", ErrCursorInUse)}
+		}
+
+		s.noCursor = true
+
+		if s.header.version >= versionCompression {
+			c := &blockCursor{
+				reader:    r,
+				format:    blockFormatOf(s.header.version),
+				verify:    verify,
+				limits:    s.limits,
 				offset:    headerSize,
 				endOffset: s.header.indexOffset,
 			}
-		}
 
-		i := s.index.entryIndexOf(key)
-		if i == -1 {
-			i = 0
+			if s.header.version >= versionFooter {
+				// The header has no index offset, so read until the
+				// end of the blocks.
+				c.endOffset = math.MaxUint64
+			}
+
+			if key != nil {
+				for !c.Done() && s.cmp.Compare(c.Entry().Key, key) < 0 {
+					c.Next()
+				}
+			}
+
+			return c
 		}
 
 		c := CursorToOffset{
 			reader:    s.reader,
-			offset:    s.index[i].blockOffset,
+			limits:    s.limits,
+			offset:    headerSize,
 			endOffset: s.header.indexOffset,
 		}
 
 		if key != nil {
-			for !c.Done() && bytes.Compare(c.Entry().Key, key) < 0 {
+			for !c.Done() && s.cmp.Compare(c.Entry().Key, key) < 0 {
 				c.Next()
 			}
 		}
 
 		return &c
-	case io.Reader:
-		if s.noCursor {
-			panic("unimplemented")
+	default:
+		return errCursor{err: fmt.Errorf("SSTable.ScanFrom: %T is not a reader: %w", r, ErrNotRandomAccess)}
+	}
+}
+
+// Get returns the value of the key. found is false if the key is not
+// in the SSTable. If the key has multiple values, it returns one of
+// them; GetAll returns all of them. It only reads the block that may
+// contain the key, and it reads nothing if the bloom filter of the
+// SSTable rules the key out. A value that is stored out of line is read
+// into memory; OpenValue streams it instead. It requires the reader to
+// be an io.ReaderAt.
+func (s *SSTable) Get(key []byte) (value []byte, found bool, err error) {
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, false, fmt.Errorf("SSTable.Get: %w", ErrNotRandomAccess)
+	}
+
+	e, ref, err := s.find(r, key)
+	if err != nil || e == nil {
+		return nil, false, err
+	}
+
+	if ref != nil {
+		if value, err = s.readValue(r, ref); err != nil {
+			return nil, false, err
 		}
 
-		s.noCursor = true
+		return value, true, nil
+	}
 
-		c := CursorToOffset{
-			reader:    s.reader,
-			offset:    headerSize,
-			endOffset: s.header.indexOffset,
+	if !s.zeroCopy() {
+		e = e.clone()
+	}
+
+	return e.Value, true, nil
+}
+
+// readValue reads the value that is stored out of line at ref into
+// memory and verifies its checksum.
+func (s *SSTable) readValue(r io.ReaderAt, ref *blobRef) ([]byte, error) {
+	if err := s.limits.checkEntry(0, ref.length); err != nil {
+		return nil, err
+	}
+
+	if size, ok := sizeOf(r); ok && ref.length > size-min(size, ref.offset) {
+		return nil, fmt.Errorf("%w: value at offset %d ends beyond the end of the file", ErrCorrupt, ref.offset)
+	}
+
+	br, err := newBlobReader(r, ref, s.header.version >= versionChecksums)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := readFull(br, ref.length)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
 		}
 
-		if key != nil {
-			for !c.Done() && bytes.Compare(c.Entry().Key, key) < 0 {
-				c.Next()
+		return nil, err
+	}
+
+	// Read to the end to verify the checksum.
+	if _, err := br.Read(nil); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// OpenValue returns a reader of the value of the key and the length of
+// the value, so that large values written by Writer.WriteValueFrom can
+// be streamed without holding them in memory. found is false if the key
+// is not in the SSTable. The reader verifies the checksum of the value
+// when it reaches the end. Cursors and iterators read large values
+// into memory like Get. It requires the reader to be an io.ReaderAt.
+func (s *SSTable) OpenValue(key []byte) (value io.Reader, length uint64, found bool, err error) {
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, 0, false, fmt.Errorf("SSTable.OpenValue: %w", ErrNotRandomAccess)
+	}
+
+	e, ref, err := s.find(r, key)
+	if err != nil || e == nil {
+		return nil, 0, false, err
+	}
+
+	if ref == nil {
+		return bytes.NewReader(e.clone().Value), uint64(len(e.Value)), true, nil
+	}
+
+	br, err := newBlobReader(r, ref, s.header.version >= versionChecksums)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return br, ref.length, true, nil
+}
+
+// find returns the entry of the key from the block that may contain it,
+// and the blobRef of the value if it is stored out of line. It returns
+// nil if the key is not in the SSTable.
+func (s *SSTable) find(r io.ReaderAt, key []byte) (*Entry, *blobRef, error) {
+	if s.filter != nil && !s.filter.mayContain(key) {
+		return nil, nil, nil
+	}
+
+	i, err := s.blockIndexOf(r, key)
+	if err != nil || i == -1 {
+		return nil, nil, err
+	}
+
+	b, err := s.readBlock(r, i, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blockFormatOf(s.header.version).find(b, key, s.cmp, s.limits)
+}
+
+// GetAll returns all the values of the key in the order that they were
+// written, even if they span blocks. It returns no values if the key
+// is not in the SSTable, and at most one if the Properties record a
+// DuplicatePolicy other than AllowDuplicates. It requires the reader to be an io.ReaderAt.
+func (s *SSTable) GetAll(key []byte) ([][]byte, error) {
+	r, ok := s.reader.(io.ReaderAt)
+	if !ok {
+		return nil, fmt.Errorf("SSTable.GetAll: %w", ErrNotRandomAccess)
+	}
+
+	if s.filter != nil && !s.filter.mayContain(key) {
+		return nil, nil
+	}
+
+	var values [][]byte
+
+	it := &Iterator{table: s, reader: r, block: -1}
+	for it.Seek(key); !it.Done() && s.cmp.Compare(it.Entry().Key, key) == 0; it.Next() {
+		values = append(values, it.Entry().Value)
+
+		// The keys of a table whose Writer didn't allow duplicates are
+		// unique.
+		if !s.allowsDuplicates() {
+			break
+		}
+	}
+
+	return values, it.Err()
+}
+
+// allowsDuplicates returns false if the Writer recorded a policy that
+// keeps the keys unique.
+func (s *SSTable) allowsDuplicates() bool {
+	return s.props == nil || s.props.Duplicates == AllowDuplicates
+}
+
+// Has returns true if the key is in the SSTable.
+func (s *SSTable) Has(key []byte) (bool, error) {
+	_, found, err := s.Get(key)
+	return found, err
+}
+
+// Comparer returns the comparer that orders the keys.
+func (s *SSTable) Comparer() Comparer {
+	return s.cmp
+}
+
+// Properties returns the properties that the Writer recorded. It
+// returns nil if the table has none, like tables of format version 2.
+// The returned Properties must not be modified.
+func (s *SSTable) Properties() *Properties {
+	return s.props
+}
+
+// Close releases the resources that the SSTable acquired itself, such
+// as the mapping of OpenMmap. Entries that point into the mapping must
+// not be used after Close. It doesn't close the reader given to
+// NewSSTable. It drops the blocks of the SSTable from the block cache.
+func (s *SSTable) Close() error {
+	if s.cache != nil {
+		s.cache.drop(s.id)
+	}
+
+	if s.closer == nil {
+		return nil
+	}
+
+	err := s.closer.Close()
+	s.closer = nil
+
+	return err
+}
+
+// ScanOptions configures the bounds of ScanRange.
+type ScanOptions struct {
+	// StartExclusive excludes the start key itself from the scan.
+	StartExclusive bool
+	// EndInclusive includes the end key itself in the scan.
+	EndInclusive bool
+	// Prefix limits the scan to the keys that start with Prefix. Unless
+	// the table is ordered by BytewiseComparer, the keys with the
+	// prefix may be anywhere, so the scan reads the whole range.
+	Prefix []byte
+	// Limit is the maximum number of entries to scan. Zero means no
+	// limit.
+	Limit int
+	// SkipChecksums skips verifying the checksums of the data blocks,
+	// which makes bulk scans faster at the cost of integrity.
+	SkipChecksums bool
+}
+
+// ScanRange scans the keys between start and end. A nil start scans
+// from the beginning and a nil end scans to the end of the SSTable.
+// The start is inclusive and the end is exclusive unless opts says
+// otherwise. The returned cursor is done at the upper bound.
+func (s *SSTable) ScanRange(start, end []byte, opts ScanOptions) Cursor {
+	var prefix []byte
+	if opts.Prefix != nil && s.cmp.Name() != BytewiseComparer.Name() {
+		prefix = opts.Prefix
+	} else if opts.Prefix != nil {
+		if start == nil || bytes.Compare(start, opts.Prefix) < 0 {
+			start, opts.StartExclusive = opts.Prefix, false
+		}
+
+		if limit := prefixSuccessor(opts.Prefix); limit != nil {
+			if end == nil || bytes.Compare(limit, end) <= 0 {
+				end, opts.EndInclusive = limit, false
 			}
 		}
+	}
 
-		return &c
-	default:
-		panic("unimplemented")
+	c := s.scanFrom(start, !opts.SkipChecksums)
+	if opts.StartExclusive && start != nil {
+		for !c.Done() && s.cmp.Compare(c.Entry().Key, start) == 0 {
+			c.Next()
+		}
+	}
+
+	return &rangeCursor{
+		Cursor:       c,
+		cmp:          s.cmp,
+		prefix:       prefix,
+		end:          end,
+		endInclusive: opts.EndInclusive,
+		limit:        opts.Limit,
 	}
 }
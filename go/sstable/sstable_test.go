@@ -94,3 +94,74 @@ func ExampleSSTable_reader() {
 	// &{[1 2 3] [5 6 7 8]}
 	// &{[2 2 3] [8 5 6 7 8]}
 }
+
+func ExampleSSTable_Get() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f)
+
+	// Each value is large enough to fill a block of its own.
+	entries := []Entry{
+		{Key: []byte("apple"), Value: bytes.Repeat([]byte{'a'}, 40000)},
+		{Key: []byte("banana"), Value: bytes.Repeat([]byte{'b'}, 40000)},
+		{Key: []byte("cherry"), Value: []byte("red")},
+	}
+	for _, entry := range entries {
+		if err := w.Write(entry); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	w.Close()
+
+	f2, _ := os.Open(name)
+	defer f2.Close()
+
+	s, _ := NewSSTable(f2)
+
+	value, found, err := s.Get([]byte("banana"))
+	fmt.Println(len(value), found, err)
+
+	value, found, err = s.Get([]byte("cherry"))
+	fmt.Println(string(value), found, err)
+
+	for _, key := range []string{"aardvark", "apricot", "blueberry", "date"} {
+		found, err := s.Has([]byte(key))
+		fmt.Println(key, found, err)
+	}
+	// Output:
+	// 40000 true <nil>
+	// red true <nil>
+	// aardvark false <nil>
+	// apricot false <nil>
+	// blueberry false <nil>
+	// date false <nil>
+}
+
+func ExampleSSTable_ScanRange() {
+	s, cleanup := newExampleTable(exampleFruits)
+	defer cleanup()
+
+	printKeys := func(c Cursor) {
+		for ; !c.Done(); c.Next() {
+			fmt.Printf("%s ", c.Entry().Key)
+		}
+
+		fmt.Println(c.Err())
+	}
+
+	printKeys(s.ScanRange([]byte("apricot"), []byte("cherry"), ScanOptions{}))
+	printKeys(s.ScanRange([]byte("apricot"), []byte("cherry"), ScanOptions{EndInclusive: true}))
+	printKeys(s.ScanRange([]byte("apricot"), nil, ScanOptions{StartExclusive: true}))
+	printKeys(s.ScanRange(nil, nil, ScanOptions{Prefix: []byte("ap")}))
+	printKeys(s.ScanRange(nil, nil, ScanOptions{Limit: 3}))
+	// Output:
+	// apricot banana <nil>
+	// apricot banana cherry <nil>
+	// banana cherry <nil>
+	// apple apricot <nil>
+	// apple apricot banana <nil>
+}
@@ -0,0 +1,380 @@
+package sstable
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ProblemKind classifies the problems that Verify finds.
+type ProblemKind uint8
+
+// Problem kinds.
+const (
+	// ProblemOpen means the table can't be opened at all, because the
+	// header, the footer, the index or the meta blocks are broken.
+	ProblemOpen ProblemKind = iota
+	// ProblemNumBlocks means the number of blocks in the header or the
+	// partitions doesn't match the index.
+	ProblemNumBlocks
+	// ProblemLayout means the blocks don't tile the file from the
+	// header to the index.
+	ProblemLayout
+	// ProblemIndex means an index partition can't be read.
+	ProblemIndex
+	// ProblemIndexKey means the key of an index entry isn't the first
+	// key of its block.
+	ProblemIndexKey
+	// ProblemUnsorted means a key is less than the key before it.
+	ProblemUnsorted
+	// ProblemChecksum means a block or a value fails its checksum.
+	ProblemChecksum
+	// ProblemBlock means a block or a value can't be read or decoded.
+	ProblemBlock
+	// ProblemProperties means the properties don't match the table.
+	ProblemProperties
+	// ProblemDuplicate means a key repeats the key before it in a table
+	// whose Writer didn't allow duplicates.
+	ProblemDuplicate
+)
+
+// problemKindNames are the names of the problem kinds.
+var problemKindNames = [...]string{
+	ProblemOpen:       "open",
+	ProblemNumBlocks:  "num-blocks",
+	ProblemLayout:     "layout",
+	ProblemIndex:      "index",
+	ProblemIndexKey:   "index-key",
+	ProblemUnsorted:   "unsorted",
+	ProblemChecksum:   "checksum",
+	ProblemBlock:      "block",
+	ProblemProperties: "properties",
+	ProblemDuplicate:  "duplicate",
+}
+
+// String returns the name of the kind.
+func (k ProblemKind) String() string {
+	if int(k) < len(problemKindNames) {
+		return problemKindNames[k]
+	}
+
+	return fmt.Sprintf("ProblemKind(%d)", k)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface, so that
+// the kinds are encoded by the name.
+func (k ProblemKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Problem is a problem that Verify finds in a table.
+type Problem struct {
+	Kind ProblemKind
+	// Offset is the offset in the file of the data with the problem.
+	Offset uint64
+	// Block is the index of the data block with the problem, or -1 if
+	// the problem isn't in a data block.
+	Block int
+	// Detail describes the problem for people.
+	Detail string
+}
+
+// String returns the problem in a line.
+func (p Problem) String() string {
+	if p.Block < 0 {
+		return fmt.Sprintf("%s at offset %d: %s", p.Kind, p.Offset, p.Detail)
+	}
+
+	return fmt.Sprintf("%s in block %d at offset %d: %s", p.Kind, p.Block, p.Offset, p.Detail)
+}
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	// Comparer is the comparer that the table must be ordered by. If it
+	// is nil, the registered comparer that the table records is used.
+	Comparer Comparer
+	// Limits bounds the lengths in the table.
+	Limits ReadLimits
+	// MaxProblems makes Verify stop after the number of problems. Zero
+	// means no limit.
+	MaxProblems int
+}
+
+// Verify walks the whole table in r and returns the problems that it
+// finds, in the order of the file. It checks that the number of blocks
+// matches the index, that the blocks tile the file from the header to
+// the index, that the keys of the index are the first keys of the
+// blocks, that the keys are sorted within and across blocks and unique
+// if the Writer didn't allow duplicates, that the properties match, and
+// all the checksums. A table that can't be opened
+// has a single problem of ProblemOpen. Verify returns an error only if
+// r doesn't report its size.
+func Verify(r io.ReaderAt, opts VerifyOptions) ([]Problem, error) {
+	size, ok := sizeOf(r)
+	if !ok {
+		return nil, fmt.Errorf("Verify: the reader has no size: %w", ErrNotRandomAccess)
+	}
+
+	ropts := []ReaderOption{WithReadLimits(opts.Limits), lenient()}
+	if opts.Comparer != nil {
+		ropts = append(ropts, ExpectComparer(opts.Comparer))
+	}
+
+	v := &verifier{r: r, size: size, max: opts.MaxProblems}
+
+	s, err := NewSSTable(r, ropts...)
+	if err != nil {
+		v.add(ProblemOpen, 0, -1, "%v", err)
+		return v.problems, nil
+	}
+
+	v.s = s
+	v.verify()
+
+	return v.problems, nil
+}
+
+// lenient makes NewSSTable leave the checks of the index to Verify,
+// which reports the problems instead of failing.
+func lenient() ReaderOption {
+	return func(s *SSTable) {
+		s.lenient = true
+	}
+}
+
+// verifier holds the state of Verify.
+type verifier struct {
+	r        io.ReaderAt
+	s        *SSTable
+	size     uint64
+	max      int
+	problems []Problem
+}
+
+// add records a problem.
+func (v *verifier) add(kind ProblemKind, offset uint64, block int, format string, args ...interface{}) {
+	if v.full() {
+		return
+	}
+
+	v.problems = append(v.problems, Problem{
+		Kind:   kind,
+		Offset: offset,
+		Block:  block,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+// full returns true if Verify should stop.
+func (v *verifier) full() bool {
+	return v.max > 0 && len(v.problems) >= v.max
+}
+
+// headerOffset returns the offset of the header that has the index
+// offset and the number of blocks.
+func (v *verifier) headerOffset() uint64 {
+	if v.s.header.version >= versionFooter {
+		return v.size - min(v.size, uint64(v.s.header.footerSize()))
+	}
+
+	return 0
+}
+
+// verify walks the table.
+func (v *verifier) verify() {
+	s := v.s
+	v.verifyNumBlocks()
+
+	format := blockFormatOf(s.header.version)
+	found := len(v.problems)
+	offset := uint64(headerSize)
+	entries := uint64(0)
+
+	var last []byte
+
+	n := s.numBlocks()
+	for i := 0; i < n && !v.full(); i++ {
+		e, err := s.blockEntry(v.r, i)
+		if err != nil {
+			v.add(ProblemIndex, 0, i, "%v", err)
+
+			if s.partitions != nil {
+				// Skip the rest of the partition.
+				bpp := int(s.partitions.blocksPerPartition)
+				i = (i/bpp+1)*bpp - 1
+			}
+
+			continue
+		}
+
+		if offset = v.skipBlobs(offset); e.blockOffset != offset {
+			v.add(ProblemLayout, e.blockOffset, i, "the block starts at offset %d, not %d", e.blockOffset, offset)
+		}
+
+		offset = e.blockOffset + uint64(e.blockLength)
+
+		es, ref := v.verifyBlock(i, e)
+		if len(es) == 0 {
+			continue
+		}
+
+		if !bytes.Equal(e.keyBytes, es[0].Key) {
+			v.add(ProblemIndexKey, e.blockOffset, i, "the index key %q isn't the first key %q", e.keyBytes, es[0].Key)
+		}
+
+		for _, entry := range es {
+			if last != nil {
+				switch c := s.cmp.Compare(last, entry.Key); {
+				case c > 0:
+					v.add(ProblemUnsorted, e.blockOffset, i, "the key %q is after %q", entry.Key, last)
+				case c == 0 && !s.allowsDuplicates():
+					v.add(ProblemDuplicate, e.blockOffset, i, "the key %q is repeated", entry.Key)
+				}
+			}
+
+			last = entry.Key
+		}
+
+		entries += uint64(len(es))
+
+		if ref != nil {
+			v.verifyBlob(i, e, ref)
+		}
+	}
+
+	if v.full() {
+		return
+	}
+
+	offset = v.skipBlobs(offset)
+
+	if terminator := format.terminator(); terminator != nil {
+		var h [blockHeaderSize]byte
+		if m, _ := v.r.ReadAt(h[:], int64(min(offset, v.size))); m != len(h) || CodecID(h[4]) != endOfBlocks { //nolint:gosec // bounded by the size
+			v.add(ProblemLayout, offset, -1, "no end of the blocks")
+		}
+
+		offset += uint64(len(terminator))
+	}
+
+	if s.partitions != nil {
+		for p, e := range s.index {
+			if e.blockOffset != offset {
+				v.add(ProblemLayout, e.blockOffset, -1, "index partition %d starts at offset %d, not %d", p, e.blockOffset, offset)
+			}
+
+			offset = e.blockOffset + uint64(e.blockLength)
+		}
+	}
+
+	if offset != s.header.indexOffset {
+		v.add(ProblemLayout, offset, -1, "the blocks end at offset %d, not at the index offset %d", offset, s.header.indexOffset)
+	}
+
+	if len(v.problems) == found {
+		v.verifyProperties(uint64(n), entries) //nolint:gosec // n is non-negative
+	}
+}
+
+// verifyNumBlocks checks the number of blocks in the header and the
+// partitions against the index.
+func (v *verifier) verifyNumBlocks() {
+	s := v.s
+	if uint64(len(s.index)) != uint64(s.header.numBlocks) {
+		v.add(ProblemNumBlocks, v.headerOffset(), -1, "the header has %d blocks, but the index has %d", s.header.numBlocks, len(s.index))
+	}
+
+	if p := s.partitions; p != nil {
+		n := p.numBlocks/uint64(p.blocksPerPartition) + min(p.numBlocks%uint64(p.blocksPerPartition), 1)
+		if n != uint64(len(s.index)) {
+			v.add(ProblemNumBlocks, s.header.indexOffset, -1, "%d blocks don't fit in %d partitions", p.numBlocks, len(s.index))
+		}
+	}
+}
+
+// verifyBlock reads the i-th block and returns its entries and the
+// blobRef of its value if the value is stored out of line. It returns
+// no entries if the block is broken.
+func (v *verifier) verifyBlock(i int, e indexEntry) ([]*Entry, *blobRef) {
+	b, err := v.s.readBlock(v.r, i, true)
+	if err != nil {
+		v.addError(e.blockOffset, i, err)
+		return nil, nil
+	}
+
+	format := blockFormatOf(v.s.header.version)
+
+	es, err := format.entries(b, nil, v.s.limits)
+	if err != nil {
+		v.add(ProblemBlock, e.blockOffset, i, "%v", err)
+		return nil, nil
+	}
+
+	if len(es) == 0 {
+		v.add(ProblemBlock, e.blockOffset, i, "the block is empty")
+		return nil, nil
+	}
+
+	if encoding, _, _ := format.encoding(b); encoding != blobEncoding {
+		return es, nil
+	}
+
+	_, ref, err := format.find(b, es[0].Key, v.s.cmp, v.s.limits)
+	if err != nil {
+		v.add(ProblemBlock, e.blockOffset, i, "%v", err)
+	}
+
+	return es, ref
+}
+
+// verifyBlob reads the value that ref locates and verifies its
+// checksum. The value must be before its block.
+func (v *verifier) verifyBlob(i int, e indexEntry, ref *blobRef) {
+	if ref.offset < headerSize+blobFrameSize || ref.length > e.blockOffset-min(e.blockOffset, ref.offset) {
+		v.add(ProblemLayout, e.blockOffset, i, "the value at offset %d isn't before its block", ref.offset)
+		return
+	}
+
+	br, err := newBlobReader(v.r, ref, blockFormatOf(v.s.header.version).checksummed)
+	if err == nil {
+		_, err = io.Copy(io.Discard, br)
+	}
+
+	if err != nil {
+		v.addError(ref.offset, i, err)
+	}
+}
+
+// addError records the error of reading a block or a value.
+func (v *verifier) addError(offset uint64, block int, err error) {
+	var corruption *CorruptionError
+	if errors.As(err, &corruption) {
+		v.add(ProblemChecksum, corruption.Offset, block, "%v", err)
+		return
+	}
+
+	v.add(ProblemBlock, offset, block, "%v", err)
+}
+
+// skipBlobs returns the offset after the blob frames at offset.
+func (v *verifier) skipBlobs(offset uint64) uint64 {
+	return skipBlobs(v.r, v.s.header.version, offset, v.s.header.indexOffset)
+}
+
+// verifyProperties checks the properties against the numbers of blocks
+// and entries of the table.
+func (v *verifier) verifyProperties(blocks, entries uint64) {
+	props := v.s.props
+	if props == nil {
+		return
+	}
+
+	if props.NumBlocks != blocks {
+		v.add(ProblemProperties, v.s.header.indexOffset, -1, "the properties have %d blocks, but the table has %d", props.NumBlocks, blocks)
+	}
+
+	if props.NumEntries != entries {
+		v.add(ProblemProperties, v.s.header.indexOffset, -1, "the properties have %d entries, but the table has %d", props.NumEntries, entries)
+	}
+}
@@ -0,0 +1,73 @@
+package sstable
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+func ExampleVerify() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithChecksums(), WithBlockSize(16))
+	for _, entry := range exampleFruits {
+		_ = w.Write(entry)
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	problems, err := Verify(bytes.NewReader(b), VerifyOptions{})
+	fmt.Println(problems, err)
+
+	// Flip a bit of the value "yellow" in the second data block.
+	b[bytes.Index(b, []byte("yellow"))] ^= 0x20
+
+	problems, _ = Verify(bytes.NewReader(b), VerifyOptions{})
+	for _, p := range problems {
+		fmt.Println(p.Kind, p.Block, p.Offset)
+		fmt.Println(p)
+	}
+
+	// Tables that can't be opened have a single problem.
+	problems, _ = Verify(bytes.NewReader(b[:20]), VerifyOptions{})
+	fmt.Println(problems)
+	// Output:
+	// [] <nil>
+	// checksum 2 71
+	// checksum in block 2 at offset 71: sstable: checksum mismatch in data block at offset 71
+	// [open at offset 0: sstable: corrupt table: index offset 126 is beyond the end of the file]
+}
+
+func ExampleVerify_numBlocks() {
+	f, _ := os.CreateTemp("", "")
+
+	name := f.Name()
+	defer os.Remove(name)
+
+	w := NewWriter(f, WithBlockSize(16))
+	for _, entry := range exampleFruits {
+		_ = w.Write(entry)
+	}
+
+	w.Close()
+
+	b, _ := os.ReadFile(name)
+
+	// Count one more block in the header and swap two keys.
+	b[7]++
+	copy(b[bytes.Index(b, []byte("apple")):], "cpple")
+
+	problems, _ := Verify(bytes.NewReader(b), VerifyOptions{})
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	// Output:
+	// num-blocks at offset 0: the header has 5 blocks, but the index has 4
+	// index-key in block 0 at offset 16: the index key "apple" isn't the first key "cpple"
+	// unsorted in block 1 at offset 32: the key "apricot" is after "cpple"
+}
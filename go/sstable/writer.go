@@ -2,61 +2,643 @@ package sstable
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strings"
+	"time"
 )
 
 // Writer is used to build a SSTable binary with Write function.
 type Writer struct {
 	indexBuffer indexBuffer
+	filter      *filterBuffer
+	version     uint32
+	codec       CodecID
+	pending     bytes.Buffer
+	pendingKey  []byte
+	prefixed    *prefixBlockBuilder
+	partitioned uint32
+	target      uint32
+	maxKeySize  int
+	maxValSize  int
+	duplicates  DuplicatePolicy
+	held        *Entry
+	heldRef     *blobRef
+	created     time.Time
+	err         error
+	blocks      index
+	offset      uint64
+	props       Properties
+	cmp         Comparer
 	lastKey     []byte
 	writer      io.Writer
 	closed      bool
 }
 
-// NewWriter creates a Writer. The given writer w should be either WriterAt or
-// WriteSeeker for random access.
-func NewWriter(w io.Writer) *Writer {
-	return &Writer{
+// WriterOption configures a Writer. Options that need a newer format
+// version raise the version of the table. Invalid options make Write
+// and Close fail.
+type WriterOption func(*Writer)
+
+// defaultBlockSize is the number of bytes of entries in a data block.
+const defaultBlockSize = 64 * 1024
+
+// WithBlockSize makes the Writer start a new data block once the
+// entries of the block reach size bytes. A size of zero or less means
+// the default of 64 KiB.
+func WithBlockSize(size int) WriterOption {
+	return func(w *Writer) {
+		w.indexBuffer.maxBlockLength = defaultBlockSize
+		if size > 0 {
+			w.indexBuffer.maxBlockLength = uint32(min(size, math.MaxUint32)) //nolint:gosec // bounded by min
+		}
+	}
+}
+
+// WithMaxKeySize makes Write reject the keys longer than size bytes. A
+// size of zero or less means no limit.
+func WithMaxKeySize(size int) WriterOption {
+	return func(w *Writer) {
+		w.maxKeySize = max(size, 0)
+	}
+}
+
+// WithMaxValueSize makes Write reject the values longer than size
+// bytes. A size of zero or less means no limit.
+func WithMaxValueSize(size int) WriterOption {
+	return func(w *Writer) {
+		w.maxValSize = max(size, 0)
+	}
+}
+
+// DuplicatePolicy decides what the Writer does with an entry whose key
+// equals the key of the previous entry.
+type DuplicatePolicy uint8
+
+// Duplicate policies.
+const (
+	// AllowDuplicates writes all the entries, so that the key has
+	// multiple values. It is the default.
+	AllowDuplicates DuplicatePolicy = iota
+	// RejectDuplicates makes Write return an error.
+	RejectDuplicates
+	// KeepFirst writes only the first entry of the key.
+	KeepFirst
+	// KeepLast writes only the last entry of the key.
+	KeepLast
+)
+
+// WithDuplicates sets the policy for entries of the same key.
+func WithDuplicates(p DuplicatePolicy) WriterOption {
+	return func(w *Writer) {
+		w.duplicates = p
+	}
+}
+
+// WithCreationTime makes the Writer record t as the creation time in
+// the Properties instead of the time of Close, so that the same entries
+// always give the same file.
+func WithCreationTime(t time.Time) WriterOption {
+	return func(w *Writer) {
+		w.created = t
+	}
+}
+
+// WithFormatVersion makes the Writer write the format version instead
+// of the oldest version that holds the other options. It is an error if
+// the version can't hold them or isn't known.
+func WithFormatVersion(version uint32) WriterOption {
+	return func(w *Writer) {
+		w.target = version
+	}
+}
+
+// WithBloomFilter makes the Writer build a bloom filter over all keys
+// with bitsPerKey bits for each key. 10 bits per key give about 1%
+// false positives. The filter needs format version 3. It hashes the
+// bytes of the keys, so it is only built for BytewiseComparer; keys
+// that another comparer considers equal may differ in bytes.
+func WithBloomFilter(bitsPerKey int) WriterOption {
+	return func(w *Writer) {
+		w.filter = &filterBuffer{bitsPerKey: max(1, bitsPerKey)}
+		w.version = max(w.version, versionMetaBlocks)
+	}
+}
+
+// WithCompression makes the Writer compress each data block with the
+// codec registered by the id. A block is stored uncompressed if the
+// codec doesn't make it smaller. Compression needs format version 4.
+func WithCompression(id CodecID) WriterOption {
+	return func(w *Writer) {
+		w.codec = id
+		w.version = max(w.version, versionCompression)
+	}
+}
+
+// WithChecksums makes the Writer end every data block, the index and
+// the meta blocks with a CRC32C checksum that readers verify. Checksums
+// need format version 5. They are opt-in: without them, not even
+// compressed tables have checksums.
+func WithChecksums() WriterOption {
+	return func(w *Writer) {
+		w.version = max(w.version, versionChecksums)
+	}
+}
+
+// WithFooter makes the Writer put the index offset and the number of
+// blocks in a footer at the end instead of overwriting the header, so
+// that it never seeks. The footer needs format version 6. NewWriter
+// picks it automatically for writers that can't seek.
+func WithFooter() WriterOption {
+	return func(w *Writer) {
+		w.version = max(w.version, versionFooter)
+	}
+}
+
+// WithProperties makes the Writer record the Properties of the table,
+// which needs format version 3. Tables of format version 3 and later
+// always record them.
+func WithProperties() WriterOption {
+	return func(w *Writer) {
+		w.version = max(w.version, versionMetaBlocks)
+	}
+}
+
+// WithPrefixCompression makes the Writer store the keys of the data
+// blocks as deltas from the previous keys, with a restart point of a
+// full key every restartInterval entries. A restartInterval of zero or
+// less means the default. It needs format version 7.
+func WithPrefixCompression(restartInterval int) WriterOption {
+	return func(w *Writer) {
+		if restartInterval <= 0 {
+			restartInterval = defaultRestartInterval
+		}
+
+		w.prefixed = &prefixBlockBuilder{restartInterval: restartInterval}
+		w.version = max(w.version, versionPrefixKeys)
+	}
+}
+
+// WithPartitionedIndex makes the Writer split the index into partitions
+// of blocksPerPartition index entries, so that SSTables only keep a
+// small index of the partitions in memory and read the partitions on
+// demand. A blocksPerPartition of zero or less means the default. It
+// needs format version 8.
+func WithPartitionedIndex(blocksPerPartition int) WriterOption {
+	return func(w *Writer) {
+		w.partitioned = defaultBlocksPerPartition
+		if blocksPerPartition > 0 {
+			w.partitioned = uint32(min(blocksPerPartition, math.MaxUint32)) //nolint:gosec // bounded by min
+		}
+
+		w.version = max(w.version, versionPartitionedIndex)
+	}
+}
+
+// WithLargeValues lets WriteValueFrom store values out of line, so
+// that values of any size are streamed in and out of the table instead
+// of being held in memory. It needs format version 10.
+func WithLargeValues() WriterOption {
+	return func(w *Writer) {
+		w.version = max(w.version, versionLargeValues)
+	}
+}
+
+// WithComparer makes the Writer require the keys to be in the order of
+// c instead of BytewiseComparer. The name of c is recorded in the
+// table, which needs format version 3.
+func WithComparer(c Comparer) WriterOption {
+	return func(w *Writer) {
+		w.cmp = c
+		if c.Name() != BytewiseComparer.Name() {
+			w.version = max(w.version, versionMetaBlocks)
+		}
+	}
+}
+
+// NewWriter creates a Writer. If the given writer w is neither a
+// seekable WriteSeeker nor a WriterAt, the Writer writes the footer
+// format that doesn't need random access. Without options, it writes
+// format version 2, which has no footer and so no magic bytes to mark
+// the file as an SSTable; WithFormatVersion(9) or later adds them.
+func NewWriter(w io.Writer, opts ...WriterOption) *Writer {
+	writer := &Writer{
 		indexBuffer: indexBuffer{
-			maxBlockLength: 64 * 1024,
+			maxBlockLength: defaultBlockSize,
 			offset:         uint64(0),
 			index:          index{},
 		},
-		writer: w,
+		version: versionLegacy,
+		cmp:     BytewiseComparer,
+		writer:  w,
+	}
+
+	for _, opt := range opts {
+		opt(writer)
+	}
+
+	if writer.cmp.Name() != BytewiseComparer.Name() {
+		writer.filter = nil
+	}
+
+	switch {
+	case writer.target == 0:
+		writer.version = max(writer.version, versionBase)
+		if !randomAccess(w) {
+			WithFooter()(writer)
+		}
+	case writer.target < versionLegacy || writer.target > versionLatest:
+		writer.err = fmt.Errorf("NewWriter: unknown format version %d", writer.target)
+	case writer.target < writer.version:
+		writer.err = fmt.Errorf("NewWriter: format version %d can't hold the options, which need %d", writer.target, writer.version)
+	default:
+		writer.version = writer.target
+	}
+
+	return writer
+}
+
+// randomAccess returns true if Close can overwrite the header of w.
+func randomAccess(w io.Writer) bool {
+	switch w := w.(type) {
+	case io.WriteSeeker:
+		// Files like pipes and terminals are WriteSeekers that fail to
+		// seek.
+		_, err := w.Seek(0, io.SeekCurrent)
+		return err == nil
+	case io.WriterAt:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeHeader writes a placeholder header that Close overwrites.
+func (w *Writer) writeHeader() error {
+	h := header{w.version, 0, 0}
+
+	offset, err := h.WriteTo(w.writer)
+	if err != nil {
+		return err
 	}
+
+	if offset < 0 {
+		return errors.New("Writer.writeHeader: invalid offset")
+	}
+	w.indexBuffer.offset = uint64(offset) //nolint:gosec // offset checked non-negative above
+	w.offset = w.indexBuffer.offset
+
+	return nil
+}
+
+// framed returns true if the Writer frames the data blocks instead of
+// writing the entries as they come.
+func (w *Writer) framed() bool {
+	return w.version >= versionCompression
+}
+
+// pendingEmpty returns true if there is no entry in the pending block.
+func (w *Writer) pendingEmpty() bool {
+	if w.prefixed != nil {
+		return w.prefixed.len() == 0
+	}
+
+	return w.pending.Len() == 0
+}
+
+// flushBlock compresses and writes the pending block.
+func (w *Writer) flushBlock() error {
+	raw := w.pending.Bytes()
+	if w.prefixed != nil {
+		if w.prefixed.len() == 0 {
+			return nil
+		}
+
+		raw = w.prefixed.finish()
+		defer w.prefixed.reset()
+	}
+
+	if len(raw) == 0 {
+		return nil
+	}
+
+	if blockFormatOf(w.version).encoded {
+		encoding := plainEncoding
+		if w.prefixed != nil {
+			encoding = prefixEncoding
+		}
+
+		raw = append([]byte{encoding}, raw...)
+	}
+
+	w.pending.Reset()
+
+	return w.writeBlock(raw, w.pendingKey)
+}
+
+// writeBlock compresses and writes the raw block whose first key is key.
+func (w *Writer) writeBlock(raw, key []byte) error {
+	data, err := blockFormatOf(w.version).encodeBlock(raw, w.codec)
+	if err != nil {
+		return err
+	}
+
+	w.blocks = append(w.blocks, indexEntry{
+		blockOffset: w.offset,
+		blockLength: uint32(len(data)), //nolint:gosec // encodeBlock checks the length
+		keyBytes:    key,
+	})
+
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+
+	w.offset += uint64(len(data))
+
+	return nil
 }
 
 // Write writes an entry. Multiple calls to the function appends
 // entries to the SSTable. The call should be made in sorted order of
 // the keys.
 func (w *Writer) Write(e Entry) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.maxKeySize > 0 && len(e.Key) > w.maxKeySize {
+		return fmt.Errorf("Writer.Write: key of %d bytes exceeds the maximum of %d", len(e.Key), w.maxKeySize)
+	}
+
+	if w.maxValSize > 0 && len(e.Value) > w.maxValSize {
+		return fmt.Errorf("Writer.Write: value of %d bytes exceeds the maximum of %d", len(e.Value), w.maxValSize)
+	}
+
+	if uint64(len(e.Key))+uint64(len(e.Value)) > math.MaxUint32-8 {
+		return fmt.Errorf("Writer.Write: entry of %d bytes doesn't fit in a block", len(e.Key)+len(e.Value))
+	}
+
+	ok, err := w.admit(e.Key)
+	if !ok || err != nil {
+		return err
+	}
+
+	return w.add(e, nil)
+}
+
+// WriteValueFrom writes an entry of the key whose value of size bytes
+// is read from r. The value is copied into the table without being held
+// in memory, so it may be larger than a block. SSTable.OpenValue
+// streams it back. It needs WithLargeValues. With KeepLast, the values
+// that a later value of the same key replaces still take space in the
+// table.
+func (w *Writer) WriteValueFrom(key []byte, r io.Reader, size int64) error {
+	ok, err := w.admitValue(key, size)
+	if !ok || err != nil {
+		return err
+	}
+
+	if err := w.writeHeld(key); err != nil {
+		return err
+	}
+
+	ref, err := w.copyValue(r, size)
+	if err != nil {
+		return err
+	}
+
+	return w.add(Entry{Key: key}, ref)
+}
+
+// admitValue checks the key and the size of a value for WriteValueFrom
+// and applies the duplicate policy. It returns false if the entry of the
+// key should be dropped.
+func (w *Writer) admitValue(key []byte, size int64) (bool, error) {
+	if w.err != nil {
+		return false, w.err
+	}
+
+	if w.version < versionLargeValues {
+		return false, fmt.Errorf("Writer.WriteValueFrom: format version %d can't hold large values", w.version)
+	}
+
+	if size < 0 {
+		return false, fmt.Errorf("Writer.WriteValueFrom: negative size %d", size)
+	}
+
+	if w.maxKeySize > 0 && len(key) > w.maxKeySize {
+		return false, fmt.Errorf("Writer.WriteValueFrom: key of %d bytes exceeds the maximum of %d", len(key), w.maxKeySize)
+	}
+
+	if w.maxValSize > 0 && size > int64(w.maxValSize) {
+		return false, fmt.Errorf("Writer.WriteValueFrom: value of %d bytes exceeds the maximum of %d", size, w.maxValSize)
+	}
+
+	if len(key) > math.MaxUint32-4-16 {
+		return false, fmt.Errorf("Writer.WriteValueFrom: key of %d bytes doesn't fit in a block", len(key))
+	}
+
+	return w.admit(key)
+}
+
+// copyValue copies the value of size bytes from r into a blob frame and
+// returns the blobRef of the value. The entry of the value is not
+// written until it is added; a frame that no entry refers to only takes
+// space, since readers skip blob frames.
+func (w *Writer) copyValue(r io.Reader, size int64) (*blobRef, error) {
 	if w.indexBuffer.offset == 0 {
-		h := header{2, 0, 0}
+		if err := w.writeHeader(); err != nil {
+			return nil, err
+		}
+	}
 
-		offset, err := h.WriteTo(w.writer)
+	frame := make([]byte, blobFrameSize)
+	frame[4] = byte(blobFrame)
+	binary.BigEndian.PutUint64(frame[blockHeaderSize:], uint64(size))
+
+	if _, err := w.writer.Write(frame); err != nil {
+		return nil, err
+	}
+
+	ref := &blobRef{offset: w.offset + blobFrameSize, length: uint64(size)}
+	w.offset = ref.offset
+
+	cw := &checksumWriter{w: w.writer}
+	n, err := io.CopyN(cw, r, size)
+	w.offset += uint64(n) //nolint:gosec // n is non-negative
+
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		// The frame is broken, so the table is.
+		w.err = fmt.Errorf("failed to copy the value: %w", err)
+		return nil, w.err
+	}
+
+	if blockFormatOf(w.version).checksummed {
+		if err := cw.writeChecksum(); err != nil {
+			w.err = fmt.Errorf("failed to write the value checksum: %w", err)
+			return nil, w.err
+		}
+
+		w.offset += checksumSize
+	}
+
+	return ref, nil
+}
+
+// admit checks the order of the key and applies the duplicate policy.
+// It returns false if the entry of the key should be dropped.
+func (w *Writer) admit(key []byte) (bool, error) {
+	if w.lastKey == nil {
+		return true, nil
+	}
+
+	switch c := w.cmp.Compare(w.lastKey, key); {
+	case c > 0:
+		return false, fmt.Errorf("key is not sorted")
+	case c == 0 && w.duplicates == RejectDuplicates:
+		return false, fmt.Errorf("Writer.Write: duplicate key %q", key)
+	case c == 0 && w.duplicates == KeepFirst:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// add writes the admitted entry, or holds it until the key changes if
+// the policy is KeepLast. ref locates the value if it is stored out of
+// line.
+func (w *Writer) add(e Entry, ref *blobRef) error {
+	if w.duplicates != KeepLast {
+		w.lastKey = e.Key
+		return w.write(e, ref)
+	}
+
+	held, heldRef := w.held, w.heldRef
+	w.held, w.heldRef = e.clone(), ref
+	w.lastKey = w.held.Key
+
+	if held == nil || w.cmp.Compare(held.Key, e.Key) == 0 {
+		return nil
+	}
+
+	return w.write(*held, heldRef)
+}
+
+// writeHeld writes the entry that KeepLast holds unless the entry of
+// key replaces it. WriteValueFrom calls it before it copies the value,
+// so that the value of an entry that a cursor streams never precedes
+// the value of another entry that isn't replaced.
+func (w *Writer) writeHeld(key []byte) error {
+	if w.held == nil || w.cmp.Compare(w.held.Key, key) == 0 {
+		return nil
+	}
+
+	held, heldRef := w.held, w.heldRef
+	w.held, w.heldRef = nil, nil
+
+	return w.write(*held, heldRef)
+}
+
+// write writes the entry that passed the checks of Write. If ref is not
+// nil, the value is stored out of line and the entry goes into a block
+// of its own.
+func (w *Writer) write(e Entry, ref *blobRef) error {
+	if w.indexBuffer.offset == 0 {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	if w.filter != nil {
+		w.filter.add(e.Key)
+	}
+
+	w.props.add(&e)
+
+	if ref != nil {
+		w.props.RawValueSize += ref.length
+		w.props.RawDataSize += ref.length
+
+		if err := w.flushBlock(); err != nil {
+			return err
+		}
+
+		raw, err := marshalBlobBlock(e.Key, ref)
 		if err != nil {
 			return err
 		}
 
-		if offset < 0 {
-			return errors.New("Writer.Write: invalid offset")
+		return w.writeBlock(raw, e.Key)
+	}
+
+	numBlocks := len(w.indexBuffer.index)
+	if err := w.indexBuffer.Write(e.Key, uint32(len(e.Value))); err != nil { //nolint:gosec // checked by Write
+		return err
+	}
+
+	if !w.framed() {
+		_, err := e.WriteTo(w.writer)
+		return err
+	}
+
+	if len(w.indexBuffer.index) > numBlocks {
+		if err := w.flushBlock(); err != nil {
+			return err
 		}
-		w.indexBuffer.offset = uint64(offset) //nolint:gosec // offset checked non-negative above
 	}
 
-	if w.lastKey != nil && bytes.Compare(w.lastKey, e.Key) > 0 {
-		return fmt.Errorf("key is not sorted")
+	if w.pendingEmpty() {
+		w.pendingKey = e.Key
 	}
 
-	w.indexBuffer.Write(e.Key, uint32(len(e.Value))) //nolint:gosec // value length bounded by practical memory limits
-	_, err := e.WriteTo(w.writer)
-	w.lastKey = e.Key
+	if w.prefixed != nil {
+		w.prefixed.add(e.Key, e.Value)
+		return nil
+	}
+
+	_, err := e.WriteTo(&w.pending)
 
 	return err
 }
 
+// Comparer returns the comparer that orders the keys.
+func (w *Writer) Comparer() Comparer {
+	return w.cmp
+}
+
+// SetProperty records a user property in the Properties of the table.
+// Names starting with "sstable." are reserved. Recording properties
+// needs format version 3.
+func (w *Writer) SetProperty(name, value string) error {
+	if w.closed {
+		return errors.New("Writer.SetProperty: already closed")
+	}
+
+	if strings.HasPrefix(name, reservedPropertyPrefix) {
+		return fmt.Errorf("Writer.SetProperty: reserved property name %q", name)
+	}
+
+	if w.target != 0 && w.target < versionMetaBlocks {
+		return fmt.Errorf("Writer.SetProperty: format version %d can't hold properties", w.target)
+	}
+
+	if w.props.User == nil {
+		w.props.User = map[string]string{}
+	}
+
+	w.props.User[name] = value
+	WithProperties()(w)
+
+	return nil
+}
+
 // Close closes the writer. It writes index at the end and overwrite
 // header at front.
 func (w *Writer) Close() error {
@@ -64,17 +646,193 @@ func (w *Writer) Close() error {
 		return errors.New("Writer.Close: already closed")
 	}
 
-	_, err := w.indexBuffer.index.WriteTo(w.writer)
-	if err != nil {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.held != nil {
+		if err := w.write(*w.held, w.heldRef); err != nil {
+			return fmt.Errorf("failed to write the last entry: %w", err)
+		}
+
+		w.held, w.heldRef = nil, nil
+	}
+
+	if w.indexBuffer.offset == 0 {
+		if err := w.writeHeader(); err != nil {
+			return fmt.Errorf("failed to write the header: %w", err)
+		}
+	}
+
+	idx, indexOffset := w.indexBuffer.index, w.indexBuffer.offset
+	if w.framed() {
+		if err := w.flushBlock(); err != nil {
+			return fmt.Errorf("failed to write the last block: %w", err)
+		}
+
+		if terminator := blockFormatOf(w.version).terminator(); terminator != nil {
+			if _, err := w.writer.Write(terminator); err != nil {
+				return fmt.Errorf("failed to write the end of the blocks: %w", err)
+			}
+
+			w.offset += uint64(len(terminator))
+		}
+
+		idx, indexOffset = w.blocks, w.offset
+	}
+
+	numBlocks, dataEnd := len(idx), indexOffset
+
+	var meta metaIndex
+
+	if w.partitioned > 0 {
+		top, err := w.writePartitions(idx)
+		if err != nil {
+			return fmt.Errorf("failed to write the index partitions: %w", err)
+		}
+
+		p := partitions{blocksPerPartition: w.partitioned, numBlocks: uint64(len(idx))}
+
+		data, err := p.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to marshal the partitions: %w", err)
+		}
+
+		meta = append(meta, metaBlock{name: partitionsMetaBlockName, data: data})
+		idx, indexOffset = top, w.offset
+	}
+
+	cw := &checksumWriter{w: w.writer}
+	if _, err := idx.WriteTo(cw); err != nil {
 		return fmt.Errorf("failed to write index to the writer: %w", err)
 	}
 
+	if w.version >= versionChecksums {
+		if err := cw.writeChecksum(); err != nil {
+			return fmt.Errorf("failed to write the index checksum: %w", err)
+		}
+	}
+
+	if w.version >= versionMetaBlocks {
+		w.props.NumBlocks = uint64(numBlocks)
+		w.props.DataSize = dataEnd - headerSize - uint64(len(blockFormatOf(w.version).terminator()))
+		w.props.FormatVersion = w.version
+		w.props.CreationTime = w.created
+		if w.props.CreationTime.IsZero() {
+			w.props.CreationTime = time.Now()
+		}
+		w.props.Comparer = w.cmp.Name()
+		w.props.BlockSize = uint64(w.indexBuffer.maxBlockLength)
+		w.props.MaxKeySize = uint64(w.maxKeySize)   //nolint:gosec // non-negative
+		w.props.MaxValueSize = uint64(w.maxValSize) //nolint:gosec // non-negative
+		w.props.Duplicates = w.duplicates
+
+		if w.prefixed != nil {
+			w.props.RestartInterval = uint64(w.prefixed.restartInterval) //nolint:gosec // positive
+		}
+
+		if w.filter != nil {
+			w.props.FilterBitsPerKey = uint64(w.filter.bitsPerKey) //nolint:gosec // positive
+		}
+
+		if w.lastKey != nil {
+			w.props.LargestKey = append([]byte{}, w.lastKey...)
+		}
+
+		props, err := w.props.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to marshal the properties: %w", err)
+		}
+
+		meta = append(meta, metaBlock{name: propertiesMetaBlockName, data: props})
+		if w.filter != nil {
+			meta = append(meta, w.filter.metaBlock())
+		}
+
+		cw = &checksumWriter{w: w.writer}
+		if _, err := meta.WriteTo(cw); err != nil {
+			return fmt.Errorf("failed to write meta blocks to the writer: %w", err)
+		}
+
+		if w.version >= versionChecksums {
+			if err := cw.writeChecksum(); err != nil {
+				return fmt.Errorf("failed to write the meta blocks checksum: %w", err)
+			}
+		}
+	}
+
+	if w.version <= versionBase && len(idx) == 0 {
+		// Like the Writer did before format version 3.
+		indexOffset = 0
+	}
+
 	h := header{
-		version:     2,
-		numBlocks:   uint32(len(w.indexBuffer.index)), //nolint:gosec // index length bounded by practical limits
-		indexOffset: w.indexBuffer.offset,
+		version:     w.version,
+		numBlocks:   uint32(len(idx)), //nolint:gosec // index length bounded by practical limits
+		indexOffset: indexOffset,
+	}
+
+	if w.version >= versionFooter {
+		data, err := h.marshalFooter()
+		if err != nil {
+			return fmt.Errorf("failed to marshal footer: %w", err)
+		}
+
+		if _, err = w.writer.Write(data); err != nil {
+			return fmt.Errorf("failed to write the footer: %w", err)
+		}
+	} else if err := w.rewriteHeader(h); err != nil {
+		return err
 	}
 
+	w.closed = true
+	if closer, ok := w.writer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// writePartitions writes the index entries of idx in partitions and
+// returns the index of the partitions.
+func (w *Writer) writePartitions(idx index) (index, error) {
+	var top index
+
+	for start := 0; start < len(idx); start += int(w.partitioned) {
+		partition := idx[start:min(start+int(w.partitioned), len(idx))]
+
+		cw := &checksumWriter{w: w.writer}
+
+		n, err := partition.WriteTo(cw)
+		if err != nil {
+			return nil, err
+		}
+
+		if w.version >= versionChecksums {
+			if err := cw.writeChecksum(); err != nil {
+				return nil, err
+			}
+
+			n += checksumSize
+		}
+
+		if n > math.MaxUint32 {
+			return nil, errors.New("Writer.writePartitions: partition too large")
+		}
+
+		top = append(top, indexEntry{
+			blockOffset: w.offset,
+			blockLength: uint32(n), //nolint:gosec // overflow checked above
+			keyBytes:    partition[0].keyBytes,
+		})
+		w.offset += uint64(n) //nolint:gosec // n is non-negative
+	}
+
+	return top, nil
+}
+
+// rewriteHeader overwrites the header at the front with h.
+func (w *Writer) rewriteHeader(h header) error {
 	switch writer := w.writer.(type) {
 	case io.WriterAt:
 		data, err := h.MarshalBinary()
@@ -97,10 +855,5 @@ func (w *Writer) Close() error {
 		return errors.New("Writer.Close: writer cannot do random access")
 	}
 
-	w.closed = true
-	if closer, ok := w.writer.(io.Closer); ok {
-		return closer.Close()
-	}
-
 	return nil
 }
@@ -1,6 +1,7 @@
 package sstable
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -35,3 +36,36 @@ func ExampleWriter() {
 	// 00000030  00 00 03 00 00 00 00 00  00 00 10 00 00 00 1f 01  |................|
 	// 00000040  02 03                                             |..|
 }
+
+func ExampleWithFormatVersion() {
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf, WithChecksums(), WithFormatVersion(3))
+	fmt.Println(w.Write(Entry{Key: []byte("apple")}))
+
+	// Newer versions than the options need are fine. A bytes.Buffer
+	// can't seek, so it needs the footer of version 6.
+	buf.Reset()
+
+	w = NewWriter(&buf, WithFormatVersion(6), WithBlockSize(1024), WithMaxKeySize(5), WithMaxValueSize(3))
+	fmt.Println(w.Write(Entry{Key: []byte("banana")}))
+	fmt.Println(w.Write(Entry{Key: []byte("kiwi"), Value: []byte("green")}))
+	fmt.Println(w.Write(Entry{Key: []byte("kiwi"), Value: []byte("red")}))
+	fmt.Println(w.Close())
+
+	s, err := NewSSTable(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	p := s.Properties()
+	fmt.Println(p.FormatVersion, p.BlockSize, p.MaxKeySize, p.MaxValueSize)
+	// Output:
+	// NewWriter: format version 3 can't hold the options, which need 5
+	// Writer.Write: key of 6 bytes exceeds the maximum of 5
+	// Writer.Write: value of 5 bytes exceeds the maximum of 3
+	// <nil>
+	// <nil>
+	// 6 1024 5 3
+}